@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/leorolland/sortir.in/pkg/application"
 	"github.com/leorolland/sortir.in/pkg/infrastructure/client/pb"
@@ -11,49 +15,90 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		slog.Error("Missing limit argument. Usage: populate <limit>")
+	timeout := flag.Duration("timeout", 0, "Global deadline for the whole run (e.g. 30m), no deadline if 0")
+	workers := flag.Int("workers", 3, "Maximum amount of collectors running in parallel, collectors run sequentially and stop on the first error if 0")
+	full := flag.Bool("full", false, "Collect all the events again instead of only the new or modified ones, resetting the cursors of incremental collectors")
+	budget := flag.Int("budget", 0, "Maximum amount of requests collectors make during the run, retries included, no limit if 0")
+	configPath := flag.String("config", "", "YAML or JSON file configuring the collectors, all collectors run with their defaults if empty")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		slog.Error("Missing limit argument. Usage: populate [-timeout <duration>] [-workers <n>] [-budget <n>] [-full] [-config <file>] <limit>")
 		os.Exit(1)
 	}
 
-	limit, err := strconv.Atoi(os.Args[1])
+	limit, err := strconv.Atoi(flag.Arg(0))
 	if err != nil {
 		slog.Error("Invalid limit argument. Must be an integer", "error", err)
 		os.Exit(1)
 	}
 
-	compositeCollector := collector.NewCompositeCollector(
-		collector.NewAllEventsCollector(),
-		collector.NewBobineCollector(),
-		collector.NewParisEventsCollector(),
-	)
-
-	eventSaver := pb.NewPBClient("http://localhost:8090")
-	populator := application.NewPopulator(compositeCollector, eventSaver)
+	// Stop cleanly on Ctrl-C or SIGTERM, in-flight requests are cancelled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	slog.Info("Populating events", "location_limit", limit)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
-	iterator := application.NewFrenchCitiesIterator()
-	locationsProcessed := 0
+	apiKey := os.Getenv("SORTIR_INGEST_API_KEY")
+	if apiKey == "" {
+		slog.Warn("SORTIR_INGEST_API_KEY is not set, locations and events will be rejected by the server")
+	}
 
-	for locationsProcessed < limit {
-		location := iterator.Next()
-		if location == nil {
-			slog.Info("No more locations available")
-			break
-		}
+	client := pb.NewPBClient("http://localhost:8090", apiKey)
 
-		slog.Info("Processing location", "city", location.City)
+	// Locations are collected by descending priority, the least recently collected first
+	locations, err := client.EnabledLocations(ctx)
+	if err != nil {
+		slog.Error("Failed to get locations", "error", err)
+		os.Exit(1)
+	}
 
-		err := populator.Populate(*location)
+	config := collector.DefaultConfig()
+	if *configPath != "" {
+		config, err = collector.LoadConfig(*configPath, locations)
 		if err != nil {
-			slog.Error("Failed to populate events", "city", location.City, "error", err)
-			continue
+			slog.Error("Invalid collectors configuration", "error", err)
+			os.Exit(1)
 		}
+	}
+
+	collectors, err := config.EnabledCollectors()
+	if err != nil {
+		slog.Error("Failed to build collectors", "error", err)
+		os.Exit(1)
+	}
+
+	var compositeCollector application.Collector
+	if *workers > 0 {
+		compositeCollector = collector.NewConcurrentCompositeCollector(*workers, collectors...)
+	} else {
+		compositeCollector = collector.NewCompositeCollector(collectors...)
+	}
+
+	populator := application.NewIncrementalPopulator(compositeCollector, client, client, *full)
+
+	slog.Info("Populating events", "location_limit", limit, "timeout", *timeout, "budget", *budget, "full", *full)
+
+	collectCtx := collector.WithCacheStats(ctx)
+	if *budget > 0 {
+		collectCtx = collector.WithRequestBudget(collectCtx, *budget)
+	}
+
+	iterator := application.NewTrackedLocationsIterator(locations, client)
+	run, err := populator.PopulateLocations(collectCtx, iterator, limit)
+	if err != nil {
+		slog.Warn("Populate interrupted", "error", err)
+	}
 
-		locationsProcessed++
-		slog.Info("Events populated successfully", "city", location.City)
+	run.Name = "populate"
+	run.Cache = collector.CacheStats(collectCtx)
+	if err := client.SaveRun(context.WithoutCancel(ctx), run); err != nil {
+		slog.Warn("Failed to save collection run", "error", err)
 	}
 
-	slog.Info("All locations processed", "count", locationsProcessed)
+	slog.Info("All locations processed", "count", run.Succeeded(), "cache", run.Cache)
 }
@@ -0,0 +1,347 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(13, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text1405416718",
+			"max": 0,
+			"min": 0,
+			"name": "fingerprint",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(14, []byte(`{
+			"autogeneratePattern": "",
+			"hidden": false,
+			"id": "text3816742950",
+			"max": 0,
+			"min": 0,
+			"name": "norm_name",
+			"pattern": "",
+			"presentable": false,
+			"primaryKey": false,
+			"required": false,
+			"system": false,
+			"type": "text"
+		}`)); err != nil {
+			return err
+		}
+
+		// add field
+		if err := collection.Fields.AddMarshaledJSONAt(15, []byte(`{
+			"hidden": false,
+			"id": "json2430581262",
+			"maxSize": 0,
+			"name": "sources",
+			"presentable": false,
+			"required": false,
+			"system": false,
+			"type": "json"
+		}`)); err != nil {
+			return err
+		}
+
+		// drop the (name, begin, end) unique index, events are now identified by their fingerprint
+		if err := json.Unmarshal([]byte(`{
+			"indexes": [
+				"-- Create the composite functional index\nCREATE INDEX idx_events_lat_lon_bbox ON events (\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lat') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lat') END),\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lon') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lon') END)\n);",
+				"CREATE INDEX idx_events_lat_lon_end\n    ON events (\n        json_extract(loc, '$.lat'),\n        json_extract(loc, '$.lon'),\n        end\n    );"
+			]
+		}`), &collection); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		if err := backfillEventsFingerprint(app); err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(`{
+			"indexes": [
+				"-- Create the composite functional index\nCREATE INDEX idx_events_lat_lon_bbox ON events (\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lat') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lat') END),\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lon') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lon') END)\n);",
+				"CREATE INDEX idx_events_lat_lon_end\n    ON events (\n        json_extract(loc, '$.lat'),\n        json_extract(loc, '$.lon'),\n        end\n    );",
+				"CREATE UNIQUE INDEX idx_events_fingerprint ON events (fingerprint);",
+				"CREATE INDEX idx_events_norm_name_begin ON events (norm_name, begin);"
+			]
+		}`), &collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// remove field
+		collection.Fields.RemoveById("text1405416718")
+
+		// remove field
+		collection.Fields.RemoveById("text3816742950")
+
+		// remove field
+		collection.Fields.RemoveById("json2430581262")
+
+		// update collection data
+		if err := json.Unmarshal([]byte(`{
+			"indexes": [
+				"CREATE UNIQUE INDEX ` + "`" + `idx_tuX5bqYCzb` + "`" + ` ON ` + "`" + `events` + "`" + ` (\n  ` + "`" + `name` + "`" + `,\n  ` + "`" + `begin` + "`" + `,\n  ` + "`" + `end` + "`" + `\n)",
+				"-- Create the composite functional index\nCREATE INDEX idx_events_lat_lon_bbox ON events (\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lat') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lat') END),\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lon') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lon') END)\n);",
+				"CREATE INDEX idx_events_lat_lon_end\n    ON events (\n        json_extract(loc, '$.lat'),\n        json_extract(loc, '$.lon'),\n        end\n    );"
+			]
+		}`), &collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	})
+}
+
+// backfillEventsFingerprint fills the identity fields of the existing events and stores their dates in UTC.
+// Events sharing a fingerprint are the same event: the others are merged into the first one, then deleted.
+// Fingerprints, titles normalization and merge rules are copies of the ones of the application at the time of this migration,
+// so that changing them later does not change what the migration does.
+func backfillEventsFingerprint(app core.App) error {
+	var rows []backfillEventRow
+	if err := app.DB().NewQuery("SELECT id, name, kind, genres, begin, end, loc, place, address, price, price_currency, source, img FROM events ORDER BY id").All(&rows); err != nil {
+		return err
+	}
+
+	fingerprints := []string{}
+	groups := make(map[string][]backfillEventRow, len(rows))
+	for _, row := range rows {
+		fingerprint := backfillFingerprint(row)
+		if _, ok := groups[fingerprint]; !ok {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+		groups[fingerprint] = append(groups[fingerprint], row)
+	}
+
+	for _, fingerprint := range fingerprints {
+		group := groups[fingerprint]
+
+		merged := newBackfillEvent(group[0])
+		for _, row := range group[1:] {
+			merged = mergeBackfillEvents(merged, newBackfillEvent(row))
+		}
+
+		for _, row := range group[1:] {
+			if _, err := app.DB().Delete("events", dbx.HashExp{"id": row.ID}).Execute(); err != nil {
+				return err
+			}
+		}
+
+		genresJSON, err := json.Marshal(merged.genres)
+		if err != nil {
+			return err
+		}
+		sourcesJSON, err := json.Marshal(merged.sources)
+		if err != nil {
+			return err
+		}
+
+		_, err = app.DB().Update("events", dbx.Params{
+			"fingerprint":    fingerprint,
+			"name":           merged.Name,
+			"norm_name":      backfillNormalizeTitle(merged.Name),
+			"kind":           merged.Kind,
+			"genres":         string(genresJSON),
+			"begin":          merged.Begin.Time().UTC().Format(time.RFC3339),
+			"end":            merged.End.Time().UTC().Format(time.RFC3339),
+			"loc":            merged.Loc.String(),
+			"place":          merged.Place,
+			"address":        merged.Address,
+			"price":          merged.Price,
+			"price_currency": merged.PriceCurrency,
+			"source":         merged.Source,
+			"sources":        string(sourcesJSON),
+			"img":            merged.Img,
+		}, dbx.HashExp{"id": group[0].ID}).Execute()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type backfillEventRow struct {
+	ID            string                 `db:"id"`
+	Name          string                 `db:"name"`
+	Kind          string                 `db:"kind"`
+	Genres        types.JSONRaw          `db:"genres"`
+	Begin         types.DateTime         `db:"begin"`
+	End           types.DateTime         `db:"end"`
+	Loc           types.JSONMap[float64] `db:"loc"`
+	Place         string                 `db:"place"`
+	Address       string                 `db:"address"`
+	Price         float64                `db:"price"`
+	PriceCurrency string                 `db:"price_currency"`
+	Source        string                 `db:"source"`
+	Img           string                 `db:"img"`
+}
+
+// backfillEvent is a stored event with its decoded genres and all its sources
+type backfillEvent struct {
+	backfillEventRow
+	genres  []string
+	sources []string
+}
+
+func newBackfillEvent(row backfillEventRow) backfillEvent {
+	event := backfillEvent{backfillEventRow: row, genres: []string{}, sources: []string{}}
+	// malformed genres are dropped, as they are when events are read
+	_ = json.Unmarshal(row.Genres, &event.genres)
+	if row.Source != "" {
+		event.sources = append(event.sources, row.Source)
+	}
+	return event
+}
+
+// priceKnown tells whether the price is known, unknown prices being stored as 0 without currency
+func (e backfillEvent) priceKnown() bool {
+	return e.Price != 0 || e.PriceCurrency != ""
+}
+
+func (e backfillEvent) locKnown() bool {
+	return e.Loc.Get("lat") != 0 || e.Loc.Get("lon") != 0
+}
+
+// richness is the amount of known fields of the event
+func (e backfillEvent) richness() int {
+	known := []bool{
+		e.Kind != "" && e.Kind != "unknown",
+		len(e.genres) > 0,
+		!e.End.IsZero(),
+		e.locKnown(),
+		e.Place != "",
+		e.Address != "",
+		e.priceKnown(),
+		e.PriceCurrency != "",
+		e.Source != "",
+		e.Img != "",
+	}
+
+	richness := 0
+	for _, isKnown := range known {
+		if isKnown {
+			richness++
+		}
+	}
+	return richness
+}
+
+// mergeBackfillEvents keeps the richer event (a on ties), fills its unknown fields with the other one
+// and remembers the sources of both
+func mergeBackfillEvents(a, b backfillEvent) backfillEvent {
+	merged, other := a, b
+	if b.richness() > a.richness() {
+		merged, other = b, a
+	}
+
+	if merged.Kind == "" || merged.Kind == "unknown" {
+		merged.Kind = other.Kind
+	}
+	if len(merged.genres) == 0 {
+		merged.genres = other.genres
+	}
+	if merged.End.IsZero() {
+		merged.End = other.End
+	}
+	if !merged.locKnown() {
+		merged.Loc = other.Loc
+	}
+	if merged.Place == "" {
+		merged.Place = other.Place
+	}
+	if merged.Address == "" {
+		merged.Address = other.Address
+	}
+	if !merged.priceKnown() {
+		merged.Price = other.Price
+	}
+	if merged.PriceCurrency == "" {
+		merged.PriceCurrency = other.PriceCurrency
+	}
+	if merged.Source == "" {
+		merged.Source = other.Source
+	}
+	if merged.Img == "" {
+		merged.Img = other.Img
+	}
+
+	sources := slices.Clone(merged.sources)
+	for _, source := range other.sources {
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	merged.sources = sources
+	return merged
+}
+
+// backfillFingerprint is made of the normalized title, the venue rounded to ~110m and the begin time rounded to 15 minutes
+func backfillFingerprint(row backfillEventRow) string {
+	key := fmt.Sprintf("%s|%.0f|%.0f|%d",
+		backfillNormalizeTitle(row.Name),
+		math.Floor(row.Loc.Get("lat")*1000),
+		math.Floor(row.Loc.Get("lon")*1000),
+		row.Begin.Time().Truncate(15*time.Minute).Unix(),
+	)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:16])
+}
+
+// backfillNormalizeTitle lowercases the title, removes its accents and punctuation
+func backfillNormalizeTitle(title string) string {
+	decomposed := norm.NFD.String(title)
+	withoutAccents := make([]rune, 0, len(decomposed))
+	for _, r := range decomposed {
+		if !unicode.IsMark(r) {
+			withoutAccents = append(withoutAccents, r)
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(string(withoutAccents)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
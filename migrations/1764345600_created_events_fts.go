@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// events_fts is a full-text index of the name, place and genres of events.
+// It does not store the indexed text (external content), triggers keep it in sync with events.
+// Diacritics are removed when tokenizing, so that searches are accent-insensitive like application.NormalizeTitle.
+// Rows are matched by rowid, which a VACUUM of data.db may renumber:
+// the server rebuilds the index on startup, see eventRepository.RebuildIndexes.
+func init() {
+	m.Register(func(app core.App) error {
+		queries := []string{
+			`CREATE VIRTUAL TABLE events_fts USING fts5(
+				name, place, genres,
+				content='events', content_rowid='rowid',
+				tokenize='unicode61 remove_diacritics 2'
+			)`,
+			`CREATE TRIGGER events_fts_insert AFTER INSERT ON events BEGIN
+				INSERT INTO events_fts(rowid, name, place, genres) VALUES (new.rowid, new.name, new.place, new.genres);
+			END`,
+			`CREATE TRIGGER events_fts_delete AFTER DELETE ON events BEGIN
+				INSERT INTO events_fts(events_fts, rowid, name, place, genres) VALUES ('delete', old.rowid, old.name, old.place, old.genres);
+			END`,
+			`CREATE TRIGGER events_fts_update AFTER UPDATE ON events BEGIN
+				INSERT INTO events_fts(events_fts, rowid, name, place, genres) VALUES ('delete', old.rowid, old.name, old.place, old.genres);
+				INSERT INTO events_fts(rowid, name, place, genres) VALUES (new.rowid, new.name, new.place, new.genres);
+			END`,
+			`INSERT INTO events_fts(events_fts) VALUES ('rebuild')`,
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		queries := []string{
+			"DROP TRIGGER IF EXISTS events_fts_insert",
+			"DROP TRIGGER IF EXISTS events_fts_delete",
+			"DROP TRIGGER IF EXISTS events_fts_update",
+			"DROP TABLE IF EXISTS events_fts",
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
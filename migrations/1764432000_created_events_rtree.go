@@ -0,0 +1,103 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// events_rtree is a spatial index of the locations of events, matched by rowid and kept in sync by triggers.
+// It replaces the json_extract functional indexes, which can only be range-scanned on latitude,
+// but for a latitude and begin index narrowing the scan of large bounds to a band of latitudes and to the window.
+//
+// events ids being text, its rowids are implicit and a VACUUM may renumber them, leaving events_rtree pointing to
+// other events. The server empties and refills it from events on startup, see eventRepository.RebuildIndexes.
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// update collection data
+		if err := json.Unmarshal([]byte(`{
+			"indexes": [
+				"CREATE UNIQUE INDEX idx_events_fingerprint ON events (fingerprint);",
+				"CREATE INDEX idx_events_norm_name_begin ON events (norm_name, begin);",
+				"CREATE INDEX idx_events_lat_begin ON events (json_extract(loc, '$.lat'), begin);"
+			]
+		}`), &collection); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		queries := []string{
+			"CREATE VIRTUAL TABLE events_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon)",
+			`CREATE TRIGGER events_rtree_insert AFTER INSERT ON events BEGIN
+				INSERT INTO events_rtree VALUES (
+					new.rowid,
+					json_extract(new.loc, '$.lat'), json_extract(new.loc, '$.lat'),
+					json_extract(new.loc, '$.lon'), json_extract(new.loc, '$.lon')
+				);
+			END`,
+			`CREATE TRIGGER events_rtree_delete AFTER DELETE ON events BEGIN
+				DELETE FROM events_rtree WHERE id = old.rowid;
+			END`,
+			`CREATE TRIGGER events_rtree_update AFTER UPDATE OF loc ON events BEGIN
+				UPDATE events_rtree SET
+					min_lat = json_extract(new.loc, '$.lat'), max_lat = json_extract(new.loc, '$.lat'),
+					min_lon = json_extract(new.loc, '$.lon'), max_lon = json_extract(new.loc, '$.lon')
+				WHERE id = new.rowid;
+			END`,
+			`INSERT INTO events_rtree
+				SELECT rowid,
+					json_extract(loc, '$.lat'), json_extract(loc, '$.lat'),
+					json_extract(loc, '$.lon'), json_extract(loc, '$.lon')
+				FROM events`,
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		queries := []string{
+			"DROP TRIGGER IF EXISTS events_rtree_insert",
+			"DROP TRIGGER IF EXISTS events_rtree_delete",
+			"DROP TRIGGER IF EXISTS events_rtree_update",
+			"DROP TABLE IF EXISTS events_rtree",
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		collection, err := app.FindCollectionByNameOrId("pbc_1687431684")
+		if err != nil {
+			return err
+		}
+
+		// update collection data
+		if err := json.Unmarshal([]byte(`{
+			"indexes": [
+				"-- Create the composite functional index\nCREATE INDEX idx_events_lat_lon_bbox ON events (\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lat') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lat') END),\n    (CASE WHEN json_valid(loc) THEN JSON_EXTRACT(loc, '$.lon') ELSE JSON_EXTRACT(json_object('pb', loc), '$.pb.lon') END)\n);",
+				"CREATE INDEX idx_events_lat_lon_end\n    ON events (\n        json_extract(loc, '$.lat'),\n        json_extract(loc, '$.lon'),\n        end\n    );",
+				"CREATE UNIQUE INDEX idx_events_fingerprint ON events (fingerprint);",
+				"CREATE INDEX idx_events_norm_name_begin ON events (norm_name, begin);"
+			]
+		}`), &collection); err != nil {
+			return err
+		}
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// events_version holds a single number incremented by triggers whenever events change,
+// so that responses derived from events can be cached until then
+func init() {
+	m.Register(func(app core.App) error {
+		queries := []string{
+			"CREATE TABLE events_version (id INTEGER PRIMARY KEY CHECK (id = 1), version INTEGER NOT NULL)",
+			"INSERT INTO events_version (id, version) VALUES (1, 0)",
+			`CREATE TRIGGER events_version_insert AFTER INSERT ON events BEGIN
+				UPDATE events_version SET version = version + 1;
+			END`,
+			`CREATE TRIGGER events_version_delete AFTER DELETE ON events BEGIN
+				UPDATE events_version SET version = version + 1;
+			END`,
+			`CREATE TRIGGER events_version_update AFTER UPDATE ON events BEGIN
+				UPDATE events_version SET version = version + 1;
+			END`,
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		queries := []string{
+			"DROP TRIGGER IF EXISTS events_version_insert",
+			"DROP TRIGGER IF EXISTS events_version_delete",
+			"DROP TRIGGER IF EXISTS events_version_update",
+			"DROP TABLE IF EXISTS events_version",
+		}
+
+		for _, query := range queries {
+			if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
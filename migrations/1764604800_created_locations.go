@@ -0,0 +1,229 @@
+package migrations
+
+import (
+	"encoding/json"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// locations are the places collected by the populate loop, seeded with the biggest french cities
+func init() {
+	m.Register(func(app core.App) error {
+		jsonData := `{
+			"createRule": null,
+			"deleteRule": null,
+			"fields": [
+				{
+					"autogeneratePattern": "[a-z0-9]{15}",
+					"hidden": false,
+					"id": "text3208210256",
+					"max": 15,
+					"min": 15,
+					"name": "id",
+					"pattern": "^[a-z0-9]+$",
+					"presentable": false,
+					"primaryKey": true,
+					"required": true,
+					"system": true,
+					"type": "text"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text1579384326",
+					"max": 0,
+					"min": 0,
+					"name": "name",
+					"pattern": "",
+					"presentable": true,
+					"primaryKey": false,
+					"required": true,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "number2499937429",
+					"max": 90,
+					"min": -90,
+					"name": "lat",
+					"onlyInt": false,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number2518964612",
+					"max": 180,
+					"min": -180,
+					"name": "lon",
+					"onlyInt": false,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "number3250311135",
+					"max": null,
+					"min": 0,
+					"name": "radius",
+					"onlyInt": false,
+					"presentable": false,
+					"required": true,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"autogeneratePattern": "",
+					"hidden": false,
+					"id": "text1400097126",
+					"max": 2,
+					"min": 0,
+					"name": "country",
+					"pattern": "^[A-Z]*$",
+					"presentable": false,
+					"primaryKey": false,
+					"required": false,
+					"system": false,
+					"type": "text"
+				},
+				{
+					"hidden": false,
+					"id": "bool1260321794",
+					"name": "enabled",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "bool"
+				},
+				{
+					"hidden": false,
+					"id": "number1655102503",
+					"max": null,
+					"min": null,
+					"name": "priority",
+					"onlyInt": true,
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "number"
+				},
+				{
+					"hidden": false,
+					"id": "date2768350236",
+					"max": "",
+					"min": "",
+					"name": "last_collected_at",
+					"presentable": false,
+					"required": false,
+					"system": false,
+					"type": "date"
+				},
+				{
+					"hidden": false,
+					"id": "autodate2990389176",
+					"name": "created",
+					"onCreate": true,
+					"onUpdate": false,
+					"presentable": false,
+					"system": false,
+					"type": "autodate"
+				},
+				{
+					"hidden": false,
+					"id": "autodate3332085495",
+					"name": "updated",
+					"onCreate": true,
+					"onUpdate": true,
+					"presentable": false,
+					"system": false,
+					"type": "autodate"
+				}
+			],
+			"id": "pbc_3485264614",
+			"indexes": [
+				"CREATE UNIQUE INDEX ` + "`" + `idx_locations_name` + "`" + ` ON ` + "`" + `locations` + "`" + ` (` + "`" + `name` + "`" + `)"
+			],
+			"listRule": null,
+			"name": "locations",
+			"system": false,
+			"type": "base",
+			"updateRule": null,
+			"viewRule": null
+		}`
+
+		collection := &core.Collection{}
+		if err := json.Unmarshal([]byte(jsonData), &collection); err != nil {
+			return err
+		}
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// the cities collected until then, copied so that changing the defaults of the application does not change the migration
+		cities := []struct {
+			name     string
+			lat, lon float64
+			radius   float64
+		}{
+			{"Paris", 48.8566, 2.3522, 10.0},
+			{"Marseille", 43.2965, 5.3698, 8.0},
+			{"Lyon", 45.7640, 4.8357, 8.0},
+			{"Toulouse", 43.6047, 1.4442, 7.0},
+			{"Nice", 43.7102, 7.2620, 6.0},
+			{"Nantes", 47.2184, -1.5536, 6.0},
+			{"Montpellier", 43.6108, 3.8767, 6.0},
+			{"Strasbourg", 48.5734, 7.7521, 6.0},
+			{"Bordeaux", 44.8378, -0.5792, 6.0},
+			{"Lille", 50.6292, 3.0573, 6.0},
+			{"Rennes", 48.1173, -1.6778, 5.0},
+			{"Reims", 49.2583, 4.0317, 5.0},
+			{"Le Havre", 49.4944, 0.1079, 5.0},
+			{"Saint-Étienne", 45.4397, 4.3872, 5.0},
+			{"Toulon", 43.1242, 5.9280, 5.0},
+			{"Angers", 47.4784, -0.5632, 5.0},
+			{"Grenoble", 45.1885, 5.7245, 5.0},
+			{"Dijon", 47.3220, 5.0415, 5.0},
+			{"Nîmes", 43.8367, 4.3601, 5.0},
+			{"Aix-en-Provence", 43.5297, 5.4474, 5.0},
+			{"Saint-Denis", 48.9358, 2.3596, 5.0},
+			{"Le Mans", 48.0061, 0.1996, 5.0},
+			{"Clermont-Ferrand", 45.7772, 3.0870, 5.0},
+			{"Tours", 47.3941, 0.6848, 5.0},
+			{"Limoges", 45.8336, 1.2611, 5.0},
+			{"Villeurbanne", 45.7712, 4.8800, 4.0},
+			{"Amiens", 49.8942, 2.2957, 4.0},
+			{"Metz", 49.1193, 6.1757, 4.0},
+			{"Besançon", 47.2380, 6.0243, 4.0},
+			{"Perpignan", 42.6986, 2.8956, 4.0},
+		}
+
+		for _, city := range cities {
+			record := core.NewRecord(collection)
+			record.Set("name", city.name)
+			record.Set("lat", city.lat)
+			record.Set("lon", city.lon)
+			record.Set("radius", city.radius)
+			record.Set("country", "FR")
+			record.Set("enabled", true)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("pbc_3485264614")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
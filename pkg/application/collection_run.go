@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -destination=mocks/mock_collection_run_repository.go -package=applicationmocks github.com/leorolland/sortir.in/pkg/application CollectionRunRepository
+type CollectionRunRepository interface {
+	SaveRun(ctx context.Context, run CollectionRun) error
+}
+
+// CollectionRun is the outcome of a populate run, kept to follow the health of the sources over time
+type CollectionRun struct {
+	// Name identifies what triggered the run, e.g. a schedule
+	Name      string        `json:"name"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+	Locations []LocationRun `json:"locations"`
+	// Cache counts the requests of collectors answered by their HTTP cache
+	Cache CacheStats `json:"cache"`
+	// Error is set when the run was interrupted
+	Error string `json:"error,omitempty"`
+}
+
+// CacheStats counts the requests of collectors by how their HTTP cache answered them
+type CacheStats struct {
+	// Hits were answered from the cache, without request
+	Hits int `json:"hits"`
+	// Revalidated were answered from the cache once the server confirmed it was not modified
+	Revalidated int `json:"revalidated"`
+	// Misses were downloaded
+	Misses int `json:"misses"`
+}
+
+// LocationRun is the outcome of populating a location
+type LocationRun struct {
+	City       string         `json:"city"`
+	Collectors []CollectorRun `json:"collectors"`
+	// Events is the amount of events saved, once deduplicated
+	Events    int `json:"events"`
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+	// Error is set when the location could not be populated
+	Error string `json:"error,omitempty"`
+}
+
+// CollectorRun is the outcome of a collector for a location
+type CollectorRun struct {
+	Collector string `json:"collector"`
+	Events    int    `json:"events"`
+	// Invalid is the amount of collected events which cannot be saved, e.g. already ended
+	Invalid    int    `json:"invalid"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+	// Incremental is set when the collector only collected what changed since its cursor
+	Incremental bool `json:"incremental,omitempty"`
+}
+
+// CollectorTotals sums the outcome of a collector over the locations of a run
+type CollectorTotals struct {
+	Collector string `json:"collector"`
+	Locations int    `json:"locations"`
+	Events    int    `json:"events"`
+	Invalid   int    `json:"invalid"`
+	Errors    int    `json:"errors"`
+}
+
+// Succeeded returns the amount of locations successfully populated
+func (r CollectionRun) Succeeded() int {
+	succeeded := 0
+	for _, location := range r.Locations {
+		if location.Error == "" {
+			succeeded++
+		}
+	}
+	return succeeded
+}
+
+// Totals sums the outcome of the locations of the run
+func (r CollectionRun) Totals() LocationRun {
+	totals := LocationRun{}
+	for _, location := range r.Locations {
+		totals.Events += location.Events
+		totals.Inserted += location.Inserted
+		totals.Updated += location.Updated
+		totals.Unchanged += location.Unchanged
+		totals.Skipped += location.Skipped
+		totals.Failed += location.Failed
+	}
+	return totals
+}
+
+// Collectors sums the outcome of each collector over the locations of the run, in order of appearance
+func (r CollectionRun) Collectors() []CollectorTotals {
+	collectors := []CollectorTotals{}
+	indexes := make(map[string]int)
+	for _, location := range r.Locations {
+		for _, collector := range location.Collectors {
+			index, ok := indexes[collector.Collector]
+			if !ok {
+				index = len(collectors)
+				indexes[collector.Collector] = index
+				collectors = append(collectors, CollectorTotals{Collector: collector.Collector})
+			}
+
+			collectors[index].Locations++
+			collectors[index].Events += collector.Events
+			collectors[index].Invalid += collector.Invalid
+			if collector.Error != "" {
+				collectors[index].Errors++
+			}
+		}
+	}
+	return collectors
+}
+
+// Silent returns the collectors which ran without error but collected no events at all,
+// a hint that their source changed. Collectors which only ran incrementally are not silent,
+// as nothing may have changed since their cursors.
+func (r CollectionRun) Silent() []string {
+	full := make(map[string]bool)
+	for _, location := range r.Locations {
+		for _, collector := range location.Collectors {
+			if !collector.Incremental {
+				full[collector.Collector] = true
+			}
+		}
+	}
+
+	silent := []string{}
+	for _, collector := range r.Collectors() {
+		if collector.Errors == 0 && collector.Events == 0 && full[collector.Collector] {
+			silent = append(silent, collector.Collector)
+		}
+	}
+	return silent
+}
+
+func newCollectorRuns(report CollectReport) []CollectorRun {
+	collectors := make([]CollectorRun, len(report.Collectors))
+	for i, collectorReport := range report.Collectors {
+		collectors[i] = CollectorRun{
+			Collector:   collectorReport.Collector,
+			Events:      collectorReport.Events,
+			Invalid:     collectorReport.Invalid,
+			DurationMs:  collectorReport.Duration.Milliseconds(),
+			Incremental: collectorReport.Incremental,
+		}
+		if collectorReport.Err != nil {
+			collectors[i].Error = collectorReport.Err.Error()
+		}
+	}
+	return collectors
+}
@@ -0,0 +1,100 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+)
+
+type reportingCollector struct{}
+
+func (c reportingCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	events, _, err := c.CollectWithReport(ctx, location)
+	return events, err
+}
+
+func (reportingCollector) CollectWithReport(ctx context.Context, location application.CollectLocation) ([]application.Event, application.CollectReport, error) {
+	report := application.CollectReport{Location: location, Collectors: []application.CollectorReport{
+		{Collector: "paris", Events: 2, Invalid: 1},
+		{Collector: "bobine", Err: errors.New("unexpected status code: 503")},
+		{Collector: "ics"},
+	}}
+	return []application.Event{{Name: "a"}, {Name: "b"}}, report, nil
+}
+
+type countingSaver struct{}
+
+func (countingSaver) SaveEvents(ctx context.Context, events []application.Event) (application.SaveReport, error) {
+	return application.SaveReport{Inserted: 1, Unchanged: 1}, nil
+}
+
+func TestPopulateLocationsRun(t *testing.T) {
+	locations := application.NewLocationsIterator([]application.CollectLocation{{City: "Paris"}, {City: "Lyon"}})
+
+	run, err := application.NewPopulator(reportingCollector{}, countingSaver{}).PopulateLocations(context.Background(), locations, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if run.StartedAt.IsZero() || run.EndedAt.Before(run.StartedAt) {
+		t.Errorf("Expected the dates of the run, got %v to %v", run.StartedAt, run.EndedAt)
+	}
+	if len(run.Locations) != 2 || run.Locations[1].City != "Lyon" || run.Locations[1].Events != 2 {
+		t.Fatalf("Expected the outcome of each location, got %+v", run.Locations)
+	}
+
+	totals := run.Totals()
+	if totals.Events != 4 || totals.Inserted != 2 || totals.Unchanged != 2 {
+		t.Errorf("Expected the totals of the locations, got %+v", totals)
+	}
+
+	collectors := run.Collectors()
+	expected := []application.CollectorTotals{
+		{Collector: "paris", Locations: 2, Events: 4, Invalid: 2},
+		{Collector: "bobine", Locations: 2, Errors: 2},
+		{Collector: "ics", Locations: 2},
+	}
+	if len(collectors) != len(expected) {
+		t.Fatalf("Expected %d collectors, got %+v", len(expected), collectors)
+	}
+	for i := range expected {
+		if collectors[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], collectors[i])
+		}
+	}
+	if run.Locations[0].Collectors[1].Error != "unexpected status code: 503" {
+		t.Errorf("Expected the error of the collector, got %q", run.Locations[0].Collectors[1].Error)
+	}
+
+	if silent := run.Silent(); len(silent) != 1 || silent[0] != "ics" {
+		t.Errorf("Expected ics to be silent, got %v", silent)
+	}
+}
+
+func TestCollectionRunSilent(t *testing.T) {
+	run := application.CollectionRun{Locations: []application.LocationRun{
+		{City: "Paris", Collectors: []application.CollectorRun{{Collector: "paris", Incremental: true}, {Collector: "ics"}}},
+		{City: "Lyon", Collectors: []application.CollectorRun{{Collector: "ics", Incremental: true}}},
+	}}
+
+	// nothing may have changed since the cursor of paris, while ics ran in full at least once
+	if silent := run.Silent(); len(silent) != 1 || silent[0] != "ics" {
+		t.Errorf("Expected only ics to be silent, got %v", silent)
+	}
+}
+
+func TestPopulateLocationsInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	run, err := application.NewPopulator(reportingCollector{}, countingSaver{}).PopulateLocations(ctx, application.NewLocationsIterator(applicationtest.FrenchCities()), 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the run to be cancelled, got %v", err)
+	}
+	if run.Error == "" || len(run.Locations) != 0 {
+		t.Errorf("Expected an interrupted run without locations, got %+v", run)
+	}
+}
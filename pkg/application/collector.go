@@ -1,5 +1,10 @@
 package application
 
+import (
+	"context"
+	"time"
+)
+
 type CollectLocation struct {
 	City   string
 	Lat    float64
@@ -7,6 +12,50 @@ type CollectLocation struct {
 	Radius float64
 }
 
+// Collector collects the events of a location.
+// A collector made of several queries returns the events of the successful ones along with the error of the others.
 type Collector interface {
-	Collect(location CollectLocation) ([]Event, error)
+	Collect(ctx context.Context, location CollectLocation) ([]Event, error)
+}
+
+// ReportingCollector is a collector made of several sources,
+// able to report the outcome of each of them
+type ReportingCollector interface {
+	Collector
+	CollectWithReport(ctx context.Context, location CollectLocation) ([]Event, CollectReport, error)
+}
+
+// CollectorReport is the outcome of a single collector for a location
+type CollectorReport struct {
+	Collector string
+	Events    int
+	// Invalid is the amount of events which cannot be saved
+	Invalid  int
+	Duration time.Duration
+	Err      error
+	// Incremental is set when the collector continued from its cursor, only collecting what changed since then
+	Incremental bool
+}
+
+// CollectReport gathers the outcome of every collector run for a location
+type CollectReport struct {
+	Location   CollectLocation
+	Collectors []CollectorReport
+}
+
+// Failed returns the reports of the collectors which returned an error
+func (r CollectReport) Failed() []CollectorReport {
+	failed := []CollectorReport{}
+	for _, report := range r.Collectors {
+		if report.Err != nil {
+			failed = append(failed, report)
+		}
+	}
+	return failed
+}
+
+// Contains reports whether loc is within the radius of the location
+func (l CollectLocation) Contains(loc EventLocation) bool {
+	// Radius is in kilometers
+	return distance(EventLocation{Lat: l.Lat, Lon: l.Lon}, loc) <= l.Radius*1000
 }
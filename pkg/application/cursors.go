@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -destination=mocks/mock_cursor_repository.go -package=applicationmocks github.com/leorolland/sortir.in/pkg/application CursorRepository
+type CursorRepository interface {
+	// Cursors returns the cursors of the sources collected for the location named city, by source
+	Cursors(ctx context.Context, city string) (map[string]string, error)
+	// SaveCursors stores the cursors of the sources collected for the location named city, by source
+	SaveCursors(ctx context.Context, city string, cursors map[string]string) error
+}
+
+// IncrementalCollector is implemented by the collectors which can only collect what is new or modified since a cursor
+type IncrementalCollector interface {
+	// CollectSince collects the events new or modified since cursor, all of them if cursor is empty,
+	// and returns the cursor of the next collect
+	CollectSince(ctx context.Context, location CollectLocation, cursor string) ([]Event, string, error)
+}
+
+// SourceCursors are the cursors of the sources of a location, which incremental collectors start from and advance
+type SourceCursors struct {
+	mu      sync.Mutex
+	cursors map[string]string
+	// changed are the sources whose cursor was advanced
+	changed map[string]bool
+}
+
+// NewSourceCursors starts from the given cursors, by source
+func NewSourceCursors(cursors map[string]string) *SourceCursors {
+	return &SourceCursors{cursors: maps.Clone(cursors)}
+}
+
+// Get returns the cursor of source, empty if it was never collected
+func (c *SourceCursors) Get(source string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cursors[source]
+}
+
+// Set advances the cursor of source. An empty or unchanged cursor is ignored, e.g. the one returned by a collector
+// which did not collect the location.
+func (c *SourceCursors) Set(source, cursor string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cursor == "" || c.cursors[source] == cursor {
+		return
+	}
+	if c.cursors == nil {
+		c.cursors = make(map[string]string)
+	}
+	if c.changed == nil {
+		c.changed = make(map[string]bool)
+	}
+	c.cursors[source] = cursor
+	c.changed[source] = true
+}
+
+// Changed returns the cursors advanced since the start, by source
+func (c *SourceCursors) Changed() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := make(map[string]string, len(c.changed))
+	for source := range c.changed {
+		changed[source] = c.cursors[source]
+	}
+	return changed
+}
+
+type sourceCursorsKey struct{}
+
+// WithSourceCursors gives the cursors of the location being collected to the collectors
+func WithSourceCursors(ctx context.Context, cursors *SourceCursors) context.Context {
+	return context.WithValue(ctx, sourceCursorsKey{}, cursors)
+}
+
+// SourceCursorsFrom returns the cursors of the location being collected, nil if the collect is not incremental
+func SourceCursorsFrom(ctx context.Context) *SourceCursors {
+	cursors, _ := ctx.Value(sourceCursorsKey{}).(*SourceCursors)
+	return cursors
+}
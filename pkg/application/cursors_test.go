@@ -0,0 +1,122 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/leorolland/sortir.in/pkg/application"
+	applicationmocks "github.com/leorolland/sortir.in/pkg/application/mocks"
+)
+
+// incrementalCollector advances the cursor of its source by one event, starting from the cursor it is given
+type incrementalCollector struct {
+	seen *[]string
+}
+
+func (c incrementalCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	cursors := application.SourceCursorsFrom(ctx)
+	if cursors == nil {
+		*c.seen = append(*c.seen, "not incremental")
+		return []application.Event{{Name: "a"}}, nil
+	}
+
+	*c.seen = append(*c.seen, cursors.Get("source"))
+	cursors.Set("source", cursors.Get("source")+"a")
+	return []application.Event{{Name: "a"}}, nil
+}
+
+// unchangedCollector returns the cursor it is given for its source, and an empty one for another
+type unchangedCollector struct{}
+
+func (unchangedCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	cursors := application.SourceCursorsFrom(ctx)
+	cursors.Set("source", cursors.Get("source"))
+	cursors.Set("other", "")
+	return []application.Event{{Name: "a"}}, nil
+}
+
+type failingSaver struct{}
+
+func (failingSaver) SaveEvents(ctx context.Context, events []application.Event) (application.SaveReport, error) {
+	return application.SaveReport{Failed: len(events)}, nil
+}
+
+func TestPopulateIncremental(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCursorRepo := applicationmocks.NewMockCursorRepository(ctrl)
+	mockCursorRepo.EXPECT().Cursors(gomock.Any(), "Paris").Return(map[string]string{"source": "a", "other": "b"}, nil)
+	mockCursorRepo.EXPECT().SaveCursors(gomock.Any(), "Paris", map[string]string{"source": "aa"}).Return(nil)
+
+	seen := []string{}
+	populator := application.NewIncrementalPopulator(incrementalCollector{seen: &seen}, noopSaver{}, mockCursorRepo, false)
+	if err := populator.Populate(context.Background(), application.CollectLocation{City: "Paris"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "a" {
+		t.Errorf("Expected the collector to start from the stored cursor, got %v", seen)
+	}
+}
+
+func TestPopulateFullResync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Stored cursors are not read, the new ones replace them
+	mockCursorRepo := applicationmocks.NewMockCursorRepository(ctrl)
+	mockCursorRepo.EXPECT().SaveCursors(gomock.Any(), "Paris", map[string]string{"source": "a"}).Return(nil)
+
+	seen := []string{}
+	populator := application.NewIncrementalPopulator(incrementalCollector{seen: &seen}, noopSaver{}, mockCursorRepo, true)
+	if err := populator.Populate(context.Background(), application.CollectLocation{City: "Paris"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "" {
+		t.Errorf("Expected the collector to start without cursor, got %v", seen)
+	}
+}
+
+func TestPopulateKeepsCursorsWhenSavingFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// SaveCursors is not expected, failed events are collected again on the next run
+	mockCursorRepo := applicationmocks.NewMockCursorRepository(ctrl)
+	mockCursorRepo.EXPECT().Cursors(gomock.Any(), "Paris").Return(map[string]string{}, nil)
+
+	seen := []string{}
+	populator := application.NewIncrementalPopulator(incrementalCollector{seen: &seen}, failingSaver{}, mockCursorRepo, false)
+	if err := populator.Populate(context.Background(), application.CollectLocation{City: "Paris"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestPopulateSkipsUnchangedCursors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// SaveCursors is not expected, no cursor was advanced
+	mockCursorRepo := applicationmocks.NewMockCursorRepository(ctrl)
+	mockCursorRepo.EXPECT().Cursors(gomock.Any(), "Lyon").Return(map[string]string{"source": "a"}, nil)
+
+	populator := application.NewIncrementalPopulator(unchangedCollector{}, noopSaver{}, mockCursorRepo, false)
+	if err := populator.Populate(context.Background(), application.CollectLocation{City: "Lyon"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestPopulateNotIncremental(t *testing.T) {
+	seen := []string{}
+	populator := application.NewPopulator(incrementalCollector{seen: &seen}, noopSaver{})
+	if err := populator.Populate(context.Background(), application.CollectLocation{City: "Paris"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "not incremental" {
+		t.Errorf("Expected no cursors, got %v", seen)
+	}
+}
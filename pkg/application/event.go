@@ -1,9 +1,18 @@
 package application
 
 import (
+	"errors"
 	"time"
 )
 
+// MaxEventDuration is the longest duration of an event that can be saved
+const MaxEventDuration = time.Hour * 24 * 15 // 15 days
+
+var (
+	ErrEventEnded   = errors.New("event already ended")
+	ErrEventTooLong = errors.New("event lasts more than 15 days")
+)
+
 type EventLocation struct {
 	Lat float64 `json:"lat"`
 	Lon float64 `json:"lon"`
@@ -21,19 +30,26 @@ type Event struct {
 	Price         *float64
 	PriceCurrency *string
 	Source        string
-	Img           string
+	// Sources are all the sources the event was collected from, when merged from several ones
+	Sources []string
+	Img     string
 }
 
 func (e Event) IsValid() bool {
+	return e.Validate() == nil
+}
+
+// Validate returns the reason why the event cannot be saved, nil if it can
+func (e Event) Validate() error {
 	// Avoid events that are terminated
 	if e.End.Before(time.Now()) {
-		return false
+		return ErrEventEnded
 	}
 
 	// Avoid events that are too long to be saved
-	if e.End.Sub(e.Begin) > time.Hour*24*15 { // 15 days
-		return false
+	if e.End.Sub(e.Begin) > MaxEventDuration {
+		return ErrEventTooLong
 	}
 
-	return true
+	return nil
 }
@@ -1,7 +1,5 @@
 package application
 
-import "time"
-
 type Bounds struct {
 	North float64
 	South float64
@@ -11,5 +9,17 @@ type Bounds struct {
 
 //go:generate go run github.com/golang/mock/mockgen -destination=mocks/mock_event_repository.go -package=applicationmocks github.com/leorolland/sortir.in/pkg/application EventRepository
 type EventRepository interface {
-	ByBoundsAndMaxDate(bounds Bounds, maxDate time.Time) ([]Pin, error)
+	// ByBoundsAndTimeWindow returns at most limit pins, one per event overlapping the window
+	ByBoundsAndTimeWindow(bounds Bounds, window TimeWindow, filter EventFilter, limit int) ([]Pin, error)
+	// ClustersByBoundsAndTimeWindow returns at most limit pins, one per grid cell and kind,
+	// located at the centroid of its events
+	ClustersByBoundsAndTimeWindow(bounds Bounds, window TimeWindow, cellSize float64, filter EventFilter, limit int) ([]Pin, error)
+	// ByID returns ErrEventNotFound if there is no event with this identifier
+	ByID(id string) (EventRecord, error)
+	// EventsByBoundsAndTimeWindow returns at most limit events overlapping the window, ordered by begin date
+	EventsByBoundsAndTimeWindow(bounds Bounds, window TimeWindow, filter EventFilter, limit int) ([]EventRecord, error)
+	// Search returns at most limit events matching all the terms of the query, from the most relevant
+	Search(query SearchQuery, limit int) ([]SearchResult, error)
+	// Version returns a number incremented whenever events are inserted, updated or deleted
+	Version() (int64, error)
 }
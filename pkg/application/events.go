@@ -0,0 +1,66 @@
+package application
+
+import "errors"
+
+// LocationTolerance is the distance in degrees (~1m) under which an event is at a requested location,
+// so that clients do not depend on float equality of coordinates
+const LocationTolerance = 0.00001
+
+// EventsAtLimit is the maximum amount of events returned at a location
+const EventsAtLimit = 500
+
+// EventsLimit is the maximum amount of events returned in bounds, as many as pins
+const EventsLimit = 5000
+
+var ErrEventNotFound = errors.New("event not found")
+
+// EventRecord is a saved event with its identifier
+type EventRecord struct {
+	ID string
+	Event
+}
+
+type EventsService interface {
+	GetEvent(id string) (EventRecord, error)
+	// GetEventsAt returns the events at loc overlapping the window, ordered by begin date
+	GetEventsAt(loc EventLocation, window TimeWindow) ([]EventRecord, error)
+	// GetEvents returns the events in bounds overlapping the window and matching the filter, ordered by begin date
+	GetEvents(bounds Bounds, window TimeWindow, filter EventFilter) ([]EventRecord, error)
+	// Search returns the events matching the query, from the most relevant, ErrEmptySearch if there is nothing to look for
+	Search(query SearchQuery) ([]SearchResult, error)
+}
+
+type events struct {
+	eventRepository EventRepository
+}
+
+func NewEvents(eventRepository EventRepository) EventsService {
+	return &events{
+		eventRepository: eventRepository,
+	}
+}
+
+func (e *events) GetEvent(id string) (EventRecord, error) {
+	return e.eventRepository.ByID(id)
+}
+
+func (e *events) GetEventsAt(loc EventLocation, window TimeWindow) ([]EventRecord, error) {
+	bounds := Bounds{
+		North: loc.Lat + LocationTolerance,
+		South: loc.Lat - LocationTolerance,
+		East:  loc.Lon + LocationTolerance,
+		West:  loc.Lon - LocationTolerance,
+	}
+	return e.eventRepository.EventsByBoundsAndTimeWindow(bounds, window, EventFilter{}, EventsAtLimit)
+}
+
+func (e *events) GetEvents(bounds Bounds, window TimeWindow, filter EventFilter) ([]EventRecord, error) {
+	return e.eventRepository.EventsByBoundsAndTimeWindow(bounds, window, filter, EventsLimit)
+}
+
+func (e *events) Search(query SearchQuery) ([]SearchResult, error) {
+	if len(query.Terms()) == 0 {
+		return nil, ErrEmptySearch
+	}
+	return e.eventRepository.Search(query, SearchLimit)
+}
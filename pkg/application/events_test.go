@@ -0,0 +1,135 @@
+package application_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/leorolland/sortir.in/pkg/application"
+	applicationmocks "github.com/leorolland/sortir.in/pkg/application/mocks"
+)
+
+func TestGetEventNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	mockEventRepo.EXPECT().
+		ByID("unknown").
+		Return(application.EventRecord{}, application.ErrEventNotFound)
+
+	eventsService := application.NewEvents(mockEventRepo)
+
+	_, err := eventsService.GetEvent("unknown")
+	if !errors.Is(err, application.ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestGetEventsAtSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	loc := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	window := application.TimeWindow{From: time.Date(2025, 11, 23, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}
+	records := []application.EventRecord{
+		{ID: "abc", Event: application.Event{Name: "Event 1", Loc: loc}},
+	}
+
+	// Events are matched with a tolerance rather than float equality
+	mockEventRepo.EXPECT().
+		EventsByBoundsAndTimeWindow(application.Bounds{
+			North: loc.Lat + application.LocationTolerance,
+			South: loc.Lat - application.LocationTolerance,
+			East:  loc.Lon + application.LocationTolerance,
+			West:  loc.Lon - application.LocationTolerance,
+		}, window, application.EventFilter{}, application.EventsAtLimit).
+		Return(records, nil)
+
+	eventsService := application.NewEvents(mockEventRepo)
+
+	actual, err := eventsService.GetEventsAt(loc, window)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(actual, records) {
+		t.Errorf("Expected %v, got %v", records, actual)
+	}
+}
+
+func TestSearchEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	eventsService := application.NewEvents(mockEventRepo)
+
+	// punctuation only, nothing to look for
+	_, err := eventsService.Search(application.SearchQuery{Text: ` "*- `})
+	if !errors.Is(err, application.ErrEmptySearch) {
+		t.Errorf("Expected ErrEmptySearch, got %v", err)
+	}
+}
+
+func TestSearchSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	query := application.SearchQuery{Text: "Soirée jazz", Window: application.TimeWindow{From: time.Date(2025, 11, 23, 0, 0, 0, 0, time.UTC)}}
+	results := []application.SearchResult{
+		{EventRecord: application.EventRecord{ID: "abc", Event: application.Event{Name: "Soirée Jazz"}}, Snippet: "<mark>Soirée</mark> <mark>Jazz</mark>"},
+	}
+
+	mockEventRepo.EXPECT().
+		Search(query, application.SearchLimit).
+		Return(results, nil)
+
+	eventsService := application.NewEvents(mockEventRepo)
+
+	actual, err := eventsService.Search(query)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(actual, results) {
+		t.Errorf("Expected %v, got %v", results, actual)
+	}
+	if terms := query.Terms(); !reflect.DeepEqual(terms, []string{"soiree", "jazz"}) {
+		t.Errorf("Expected normalized terms, got %v", terms)
+	}
+}
+
+func TestGetEventsSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	bounds := application.Bounds{North: 48.9, South: 48.8, East: 2.4, West: 2.3}
+	window := application.TimeWindow{From: time.Date(2025, 11, 23, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}
+	filter := application.EventFilter{Kinds: []application.Kind{application.KindConcert}}
+	records := []application.EventRecord{
+		{ID: "abc", Event: application.Event{Name: "Event 1", Kind: application.KindConcert}},
+	}
+
+	mockEventRepo.EXPECT().
+		EventsByBoundsAndTimeWindow(bounds, window, filter, application.EventsLimit).
+		Return(records, nil)
+
+	eventsService := application.NewEvents(mockEventRepo)
+
+	actual, err := eventsService.GetEvents(bounds, window, filter)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(actual, records) {
+		t.Errorf("Expected %v, got %v", records, actual)
+	}
+}
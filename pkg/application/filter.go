@@ -0,0 +1,42 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EventFilter restricts the events returned by queries, its zero value matches all events
+type EventFilter struct {
+	// Kinds keeps only the events of these kinds, all kinds are kept if empty
+	Kinds        []Kind
+	ExcludeKinds []Kind
+	// Genres keeps only the events having a genre containing one of these keywords, case insensitively
+	Genres []string
+	// FreeOnly keeps only the events known to be free, i.e. with a price of 0 and a currency
+	FreeOnly bool
+	// MaxPrice keeps only the events with a known price lower or equal to it
+	MaxPrice *float64
+}
+
+func (f EventFilter) Validate() error {
+	errs := []error{}
+
+	for _, kind := range append(append([]Kind{}, f.Kinds...), f.ExcludeKinds...) {
+		if !kind.IsKnown() {
+			errs = append(errs, fmt.Errorf("unknown kind %q", kind))
+		}
+	}
+
+	for _, genre := range f.Genres {
+		if strings.TrimSpace(genre) == "" {
+			errs = append(errs, errors.New("empty genre"))
+		}
+	}
+
+	if f.MaxPrice != nil && *f.MaxPrice < 0 {
+		errs = append(errs, errors.New("max price must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package application
+
+import "testing"
+
+func TestEventFilterValidate(t *testing.T) {
+	negative := -1.0
+	zero := 0.0
+
+	tests := map[string]struct {
+		filter  EventFilter
+		isValid bool
+	}{
+		"when empty": {
+			filter:  EventFilter{},
+			isValid: true,
+		},
+		"when complete": {
+			filter:  EventFilter{Kinds: []Kind{KindConcert}, ExcludeKinds: []Kind{KindMovie}, Genres: []string{"jazz"}, FreeOnly: true, MaxPrice: &zero},
+			isValid: true,
+		},
+		"when kind is unknown": {
+			filter:  EventFilter{Kinds: []Kind{"opera"}},
+			isValid: false,
+		},
+		"when excluded kind is unknown": {
+			filter:  EventFilter{ExcludeKinds: []Kind{"opera"}},
+			isValid: false,
+		},
+		"when genre is blank": {
+			filter:  EventFilter{Genres: []string{" "}},
+			isValid: false,
+		},
+		"when max price is negative": {
+			filter:  EventFilter{MaxPrice: &negative},
+			isValid: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.filter.Validate()
+			if test.isValid && err != nil {
+				t.Errorf("expected valid filter, got %v", err)
+			}
+			if !test.isValid && err == nil {
+				t.Errorf("expected invalid filter, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Tolerances under which two events with the same normalized title are the same event.
+// The fingerprint grid is finer than them, so that events with the same fingerprint are always the same event.
+const (
+	SameEventMaxDistance   = 200.0 // meters
+	SameEventMaxBeginDelta = 15 * time.Minute
+
+	fingerprintLocPrecision = 1000.0 // ~110m cells
+)
+
+func RemoveAccents(s string) string {
+	t := norm.NFD.String(s)
+	result := make([]rune, 0, len(t))
+	for _, r := range t {
+		if unicode.IsMark(r) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
+
+// NormalizeTitle lowercases the title, removes its accents and punctuation,
+// so that titles written slightly differently by different sources are equal
+func NormalizeTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(RemoveAccents(title)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Fingerprint is a deterministic identity of the event,
+// made of its normalized title, its venue rounded to ~110m and its begin time rounded to 15 minutes
+func (e Event) Fingerprint() string {
+	key := fmt.Sprintf("%s|%.0f|%.0f|%d",
+		NormalizeTitle(e.Name),
+		math.Floor(e.Loc.Lat*fingerprintLocPrecision),
+		math.Floor(e.Loc.Lon*fingerprintLocPrecision),
+		e.Begin.Truncate(SameEventMaxBeginDelta).Unix(),
+	)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:16])
+}
+
+// IsSameEvent tells whether both events are the same, possibly collected from different sources
+func (e Event) IsSameEvent(other Event) bool {
+	if NormalizeTitle(e.Name) != NormalizeTitle(other.Name) {
+		return false
+	}
+
+	beginDelta := e.Begin.Sub(other.Begin).Abs()
+	if beginDelta > SameEventMaxBeginDelta {
+		return false
+	}
+
+	return distance(e.Loc, other.Loc) <= SameEventMaxDistance
+}
+
+// AllSources returns the source of the event followed by the other sources it was collected from
+func (e Event) AllSources() []string {
+	sources := []string{}
+	if e.Source != "" {
+		sources = append(sources, e.Source)
+	}
+	for _, source := range e.Sources {
+		if source != "" && !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// richness is the amount of known fields of the event
+func (e Event) richness() int {
+	known := []bool{
+		e.Kind != "" && e.Kind != KindUnknown,
+		len(e.Genres) > 0,
+		!e.End.IsZero(),
+		e.Loc != EventLocation{},
+		e.Place != "",
+		e.Address != "",
+		e.Price != nil,
+		e.PriceCurrency != nil,
+		e.Source != "",
+		e.Img != "",
+	}
+
+	richness := 0
+	for _, isKnown := range known {
+		if isKnown {
+			richness++
+		}
+	}
+	return richness
+}
+
+// MergeEvents merges two records of the same event.
+// The richer record is kept (a on ties), its unknown fields are filled with the other one,
+// and the sources of both records are remembered.
+func MergeEvents(a, b Event) Event {
+	merged, other := a, b
+	if b.richness() > a.richness() {
+		merged, other = b, a
+	}
+
+	if merged.Kind == "" || merged.Kind == KindUnknown {
+		merged.Kind = other.Kind
+	}
+	if len(merged.Genres) == 0 {
+		merged.Genres = other.Genres
+	}
+	if merged.End.IsZero() {
+		merged.End = other.End
+	}
+	if merged.Loc == (EventLocation{}) {
+		merged.Loc = other.Loc
+	}
+	if merged.Place == "" {
+		merged.Place = other.Place
+	}
+	if merged.Address == "" {
+		merged.Address = other.Address
+	}
+	if merged.Price == nil {
+		merged.Price = other.Price
+	}
+	if merged.PriceCurrency == nil {
+		merged.PriceCurrency = other.PriceCurrency
+	}
+	if merged.Source == "" {
+		merged.Source = other.Source
+	}
+	if merged.Img == "" {
+		merged.Img = other.Img
+	}
+
+	merged.Sources = mergeSources(merged.AllSources(), other.AllSources())
+	return merged
+}
+
+// UpdateEvent returns the record to store when incoming is the same event as the existing one.
+// A source updating its own event replaces it, otherwise records are merged, preferring the incoming one on ties.
+func UpdateEvent(existing, incoming Event) Event {
+	if incoming.Source == existing.Source {
+		incoming.Sources = mergeSources(incoming.AllSources(), existing.AllSources())
+		return incoming
+	}
+	return MergeEvents(incoming, existing)
+}
+
+// DeduplicateEvents merges the events which are the same, keeping the order of first appearance
+func DeduplicateEvents(events []Event) []Event {
+	uniqueEvents := make([]Event, 0, len(events))
+	indexesByTitle := make(map[string][]int)
+
+	for _, event := range events {
+		title := NormalizeTitle(event.Name)
+
+		merged := false
+		for _, i := range indexesByTitle[title] {
+			if uniqueEvents[i].IsSameEvent(event) {
+				uniqueEvents[i] = MergeEvents(uniqueEvents[i], event)
+				merged = true
+				break
+			}
+		}
+
+		if !merged {
+			indexesByTitle[title] = append(indexesByTitle[title], len(uniqueEvents))
+			uniqueEvents = append(uniqueEvents, event)
+		}
+	}
+
+	return uniqueEvents
+}
+
+func mergeSources(a, b []string) []string {
+	sources := slices.Clone(a)
+	for _, source := range b {
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// distance returns the great-circle distance between two locations, in meters
+func distance(a, b EventLocation) float64 {
+	const earthRadius = 6371000.0
+
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	deltaLat := (b.Lat - a.Lat) * math.Pi / 180
+	deltaLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,144 @@
+package application
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNormalizeTitleSuccess(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"when lowercasing":              {input: "Jazz À La Villette", expected: "jazz a la villette"},
+		"when removing punctuation":     {input: "L'Étranger (VOST) - 2025!", expected: "l etranger vost 2025"},
+		"when collapsing spaces":        {input: "  Concert    Piano  ", expected: "concert piano"},
+		"when already normalized":       {input: "concert piano", expected: "concert piano"},
+		"when only made of punctuation": {input: "- !", expected: ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := NormalizeTitle(test.input)
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsSameEventSuccess(t *testing.T) {
+	begin := time.Date(2025, 11, 26, 20, 0, 0, 0, time.UTC)
+	event := Event{Name: "Concert Piano", Begin: begin, Loc: EventLocation{Lat: 48.8566, Lon: 2.3522}}
+
+	tests := map[string]struct {
+		other    Event
+		expected bool
+	}{
+		"when written differently": {
+			other:    Event{Name: "CONCERT - piano", Begin: begin, Loc: event.Loc},
+			expected: true,
+		},
+		"when in another timezone": {
+			other:    Event{Name: "Concert Piano", Begin: begin.In(time.FixedZone("CET", 3600)), Loc: event.Loc},
+			expected: true,
+		},
+		"when close in time and place": {
+			other:    Event{Name: "Concert Piano", Begin: begin.Add(10 * time.Minute), Loc: EventLocation{Lat: 48.8570, Lon: 2.3530}},
+			expected: true,
+		},
+		"when another title": {
+			other:    Event{Name: "Concert Violon", Begin: begin, Loc: event.Loc},
+			expected: false,
+		},
+		"when too late": {
+			other:    Event{Name: "Concert Piano", Begin: begin.Add(time.Hour), Loc: event.Loc},
+			expected: false,
+		},
+		"when too far": {
+			other:    Event{Name: "Concert Piano", Begin: begin, Loc: EventLocation{Lat: 48.8666, Lon: 2.3522}},
+			expected: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := event.IsSameEvent(test.other); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFingerprintSuccess(t *testing.T) {
+	begin := time.Date(2025, 11, 26, 20, 0, 0, 0, time.UTC)
+	event := Event{Name: "Concert Piano", Begin: begin, Loc: EventLocation{Lat: 48.8566, Lon: 2.3522}, Source: "https://a.example"}
+
+	same := Event{Name: "concert piano!", Begin: begin.Add(5 * time.Minute).In(time.FixedZone("CET", 3600)), Loc: EventLocation{Lat: 48.8567, Lon: 2.3523}, Source: "https://b.example"}
+	if event.Fingerprint() != same.Fingerprint() {
+		t.Errorf("expected same fingerprints, got %s and %s", event.Fingerprint(), same.Fingerprint())
+	}
+
+	other := Event{Name: "Concert Piano", Begin: begin.Add(24 * time.Hour), Loc: event.Loc}
+	if event.Fingerprint() == other.Fingerprint() {
+		t.Errorf("expected different fingerprints, got %s", event.Fingerprint())
+	}
+}
+
+func TestMergeEventsKeepsRicherRecord(t *testing.T) {
+	price := 12.0
+	currency := "EUR"
+	poor := Event{Name: "concert piano", Kind: KindUnknown, Place: "Salle Pleyel", Source: "https://a.example"}
+	rich := Event{Name: "Concert Piano", Kind: KindConcert, Genres: []string{"Classical"}, Price: &price, PriceCurrency: &currency, Source: "https://b.example"}
+
+	merged := MergeEvents(poor, rich)
+
+	if merged.Name != rich.Name || merged.Kind != KindConcert || merged.Source != rich.Source {
+		t.Errorf("expected the richer record to be kept, got %+v", merged)
+	}
+	if merged.Place != poor.Place {
+		t.Errorf("expected place to be filled with %q, got %q", poor.Place, merged.Place)
+	}
+	if expected := []string{"https://b.example", "https://a.example"}; !slices.Equal(merged.Sources, expected) {
+		t.Errorf("expected sources %v, got %v", expected, merged.Sources)
+	}
+}
+
+func TestUpdateEventSameSourceReplaces(t *testing.T) {
+	price := 12.0
+	existing := Event{Name: "Concert Piano", Place: "Salle Pleyel", Price: &price, Source: "https://a.example", Sources: []string{"https://a.example", "https://b.example"}}
+	incoming := Event{Name: "Concert Piano", Place: "Philharmonie", Source: "https://a.example"}
+
+	updated := UpdateEvent(existing, incoming)
+
+	if updated.Place != "Philharmonie" || updated.Price != nil {
+		t.Errorf("expected the incoming record to replace the existing one, got %+v", updated)
+	}
+	if expected := []string{"https://a.example", "https://b.example"}; !slices.Equal(updated.Sources, expected) {
+		t.Errorf("expected sources %v, got %v", expected, updated.Sources)
+	}
+}
+
+func TestDeduplicateEventsSuccess(t *testing.T) {
+	begin := time.Date(2025, 11, 26, 20, 0, 0, 0, time.UTC)
+	loc := EventLocation{Lat: 48.8566, Lon: 2.3522}
+
+	events := DeduplicateEvents([]Event{
+		{Name: "Concert Piano", Begin: begin, Loc: loc, Source: "https://a.example"},
+		{Name: "Expo Photo", Begin: begin, Loc: loc, Source: "https://a.example"},
+		{Name: "concert piano", Begin: begin.Add(5 * time.Minute), Loc: loc, Place: "Salle Pleyel", Source: "https://b.example"},
+		{Name: "Concert Piano", Begin: begin.Add(24 * time.Hour), Loc: loc, Source: "https://a.example"},
+	})
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Place != "Salle Pleyel" {
+		t.Errorf("expected duplicated events to be merged, got %+v", events[0])
+	}
+	if expected := []string{"https://b.example", "https://a.example"}; !slices.Equal(events[0].AllSources(), expected) {
+		t.Errorf("expected sources %v, got %v", expected, events[0].AllSources())
+	}
+	if events[1].Name != "Expo Photo" || !events[2].Begin.Equal(begin.Add(24*time.Hour)) {
+		t.Errorf("expected order of first appearance to be kept, got %+v", events)
+	}
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -25,6 +26,16 @@ const (
 	KindSolidarity     Kind = "solidarity"
 )
 
+// Kinds are all the known kinds
+var Kinds = []Kind{
+	KindUnknown, KindConcert, KindTheater, KindMovie, KindFestival, KindParty, KindKaraoke, KindBusiness,
+	KindFoodDrinks, KindSports, KindExhibitions, KindHealthWellness, KindCircus, KindWorkshop, KindFleaMarket, KindSolidarity,
+}
+
+func (k Kind) IsKnown() bool {
+	return slices.Contains(Kinds, k)
+}
+
 func KindFromString(s string) Kind {
 	s = strings.ToLower(s)
 
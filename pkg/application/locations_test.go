@@ -0,0 +1,51 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/leorolland/sortir.in/pkg/application"
+	applicationmocks "github.com/leorolland/sortir.in/pkg/application/mocks"
+)
+
+type failingCollector struct {
+	city string
+}
+
+func (c failingCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	if location.City == c.city {
+		return nil, errors.New("collect failed")
+	}
+	return []application.Event{}, nil
+}
+
+type noopSaver struct{}
+
+func (noopSaver) SaveEvents(ctx context.Context, events []application.Event) (application.SaveReport, error) {
+	return application.SaveReport{}, nil
+}
+
+func TestPopulateTrackedLocations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLocationRepo := applicationmocks.NewMockLocationRepository(ctrl)
+
+	// Paris fails, so it stays the stalest location
+	mockLocationRepo.EXPECT().MarkCollected(gomock.Any(), "Lyon", gomock.Any()).Return(nil)
+	mockLocationRepo.EXPECT().MarkCollected(gomock.Any(), "Nantes", gomock.Any()).Return(application.ErrLocationNotFound)
+
+	locations := []application.CollectLocation{{City: "Lyon"}, {City: "Paris"}, {City: "Nantes"}}
+	iterator := application.NewTrackedLocationsIterator(locations, mockLocationRepo)
+
+	populator := application.NewPopulator(failingCollector{city: "Paris"}, noopSaver{})
+	run, err := populator.PopulateLocations(context.Background(), iterator, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if run.Succeeded() != 2 {
+		t.Errorf("Expected 2 locations processed, got %d", run.Succeeded())
+	}
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/leorolland/sortir.in/pkg/application (interfaces: CollectionRunRepository)
+
+// Package applicationmocks is a generated GoMock package.
+package applicationmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	application "github.com/leorolland/sortir.in/pkg/application"
+)
+
+// MockCollectionRunRepository is a mock of CollectionRunRepository interface.
+type MockCollectionRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCollectionRunRepositoryMockRecorder
+}
+
+// MockCollectionRunRepositoryMockRecorder is the mock recorder for MockCollectionRunRepository.
+type MockCollectionRunRepositoryMockRecorder struct {
+	mock *MockCollectionRunRepository
+}
+
+// NewMockCollectionRunRepository creates a new mock instance.
+func NewMockCollectionRunRepository(ctrl *gomock.Controller) *MockCollectionRunRepository {
+	mock := &MockCollectionRunRepository{ctrl: ctrl}
+	mock.recorder = &MockCollectionRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCollectionRunRepository) EXPECT() *MockCollectionRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// SaveRun mocks base method.
+func (m *MockCollectionRunRepository) SaveRun(arg0 context.Context, arg1 application.CollectionRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRun", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRun indicates an expected call of SaveRun.
+func (mr *MockCollectionRunRepositoryMockRecorder) SaveRun(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRun", reflect.TypeOf((*MockCollectionRunRepository)(nil).SaveRun), arg0, arg1)
+}
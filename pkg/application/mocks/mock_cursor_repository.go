@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/leorolland/sortir.in/pkg/application (interfaces: CursorRepository)
+
+// Package applicationmocks is a generated GoMock package.
+package applicationmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCursorRepository is a mock of CursorRepository interface.
+type MockCursorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCursorRepositoryMockRecorder
+}
+
+// MockCursorRepositoryMockRecorder is the mock recorder for MockCursorRepository.
+type MockCursorRepositoryMockRecorder struct {
+	mock *MockCursorRepository
+}
+
+// NewMockCursorRepository creates a new mock instance.
+func NewMockCursorRepository(ctrl *gomock.Controller) *MockCursorRepository {
+	mock := &MockCursorRepository{ctrl: ctrl}
+	mock.recorder = &MockCursorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCursorRepository) EXPECT() *MockCursorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Cursors mocks base method.
+func (m *MockCursorRepository) Cursors(arg0 context.Context, arg1 string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cursors", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cursors indicates an expected call of Cursors.
+func (mr *MockCursorRepositoryMockRecorder) Cursors(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cursors", reflect.TypeOf((*MockCursorRepository)(nil).Cursors), arg0, arg1)
+}
+
+// SaveCursors mocks base method.
+func (m *MockCursorRepository) SaveCursors(arg0 context.Context, arg1 string, arg2 map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCursors", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCursors indicates an expected call of SaveCursors.
+func (mr *MockCursorRepositoryMockRecorder) SaveCursors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCursors", reflect.TypeOf((*MockCursorRepository)(nil).SaveCursors), arg0, arg1, arg2)
+}
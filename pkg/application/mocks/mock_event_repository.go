@@ -6,7 +6,6 @@ package applicationmocks
 
 import (
 	reflect "reflect"
-	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	application "github.com/leorolland/sortir.in/pkg/application"
@@ -35,17 +34,92 @@ func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
 	return m.recorder
 }
 
-// ByBoundsAndMaxDate mocks base method.
-func (m *MockEventRepository) ByBoundsAndMaxDate(arg0 application.Bounds, arg1 time.Time) ([]application.Pin, error) {
+// ByBoundsAndTimeWindow mocks base method.
+func (m *MockEventRepository) ByBoundsAndTimeWindow(arg0 application.Bounds, arg1 application.TimeWindow, arg2 application.EventFilter, arg3 int) ([]application.Pin, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ByBoundsAndMaxDate", arg0, arg1)
+	ret := m.ctrl.Call(m, "ByBoundsAndTimeWindow", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]application.Pin)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ByBoundsAndMaxDate indicates an expected call of ByBoundsAndMaxDate.
-func (mr *MockEventRepositoryMockRecorder) ByBoundsAndMaxDate(arg0, arg1 interface{}) *gomock.Call {
+// ByBoundsAndTimeWindow indicates an expected call of ByBoundsAndTimeWindow.
+func (mr *MockEventRepositoryMockRecorder) ByBoundsAndTimeWindow(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByBoundsAndMaxDate", reflect.TypeOf((*MockEventRepository)(nil).ByBoundsAndMaxDate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByBoundsAndTimeWindow", reflect.TypeOf((*MockEventRepository)(nil).ByBoundsAndTimeWindow), arg0, arg1, arg2, arg3)
+}
+
+// ByID mocks base method.
+func (m *MockEventRepository) ByID(arg0 string) (application.EventRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByID", arg0)
+	ret0, _ := ret[0].(application.EventRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByID indicates an expected call of ByID.
+func (mr *MockEventRepositoryMockRecorder) ByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByID", reflect.TypeOf((*MockEventRepository)(nil).ByID), arg0)
+}
+
+// ClustersByBoundsAndTimeWindow mocks base method.
+func (m *MockEventRepository) ClustersByBoundsAndTimeWindow(arg0 application.Bounds, arg1 application.TimeWindow, arg2 float64, arg3 application.EventFilter, arg4 int) ([]application.Pin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClustersByBoundsAndTimeWindow", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]application.Pin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClustersByBoundsAndTimeWindow indicates an expected call of ClustersByBoundsAndTimeWindow.
+func (mr *MockEventRepositoryMockRecorder) ClustersByBoundsAndTimeWindow(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClustersByBoundsAndTimeWindow", reflect.TypeOf((*MockEventRepository)(nil).ClustersByBoundsAndTimeWindow), arg0, arg1, arg2, arg3, arg4)
+}
+
+// EventsByBoundsAndTimeWindow mocks base method.
+func (m *MockEventRepository) EventsByBoundsAndTimeWindow(arg0 application.Bounds, arg1 application.TimeWindow, arg2 application.EventFilter, arg3 int) ([]application.EventRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EventsByBoundsAndTimeWindow", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]application.EventRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EventsByBoundsAndTimeWindow indicates an expected call of EventsByBoundsAndTimeWindow.
+func (mr *MockEventRepositoryMockRecorder) EventsByBoundsAndTimeWindow(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EventsByBoundsAndTimeWindow", reflect.TypeOf((*MockEventRepository)(nil).EventsByBoundsAndTimeWindow), arg0, arg1, arg2, arg3)
+}
+
+// Search mocks base method.
+func (m *MockEventRepository) Search(arg0 application.SearchQuery, arg1 int) ([]application.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1)
+	ret0, _ := ret[0].([]application.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockEventRepositoryMockRecorder) Search(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockEventRepository)(nil).Search), arg0, arg1)
+}
+
+// Version mocks base method.
+func (m *MockEventRepository) Version() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockEventRepositoryMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockEventRepository)(nil).Version))
 }
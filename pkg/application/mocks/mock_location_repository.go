@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/leorolland/sortir.in/pkg/application (interfaces: LocationRepository)
+
+// Package applicationmocks is a generated GoMock package.
+package applicationmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	application "github.com/leorolland/sortir.in/pkg/application"
+)
+
+// MockLocationRepository is a mock of LocationRepository interface.
+type MockLocationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocationRepositoryMockRecorder
+}
+
+// MockLocationRepositoryMockRecorder is the mock recorder for MockLocationRepository.
+type MockLocationRepositoryMockRecorder struct {
+	mock *MockLocationRepository
+}
+
+// NewMockLocationRepository creates a new mock instance.
+func NewMockLocationRepository(ctrl *gomock.Controller) *MockLocationRepository {
+	mock := &MockLocationRepository{ctrl: ctrl}
+	mock.recorder = &MockLocationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocationRepository) EXPECT() *MockLocationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// EnabledLocations mocks base method.
+func (m *MockLocationRepository) EnabledLocations(arg0 context.Context) ([]application.CollectLocation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnabledLocations", arg0)
+	ret0, _ := ret[0].([]application.CollectLocation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnabledLocations indicates an expected call of EnabledLocations.
+func (mr *MockLocationRepositoryMockRecorder) EnabledLocations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnabledLocations", reflect.TypeOf((*MockLocationRepository)(nil).EnabledLocations), arg0)
+}
+
+// MarkCollected mocks base method.
+func (m *MockLocationRepository) MarkCollected(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkCollected", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkCollected indicates an expected call of MarkCollected.
+func (mr *MockLocationRepositoryMockRecorder) MarkCollected(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkCollected", reflect.TypeOf((*MockLocationRepository)(nil).MarkCollected), arg0, arg1, arg2)
+}
@@ -3,23 +3,46 @@ package application
 import (
 	"cmp"
 	"fmt"
+	"math"
 	"slices"
 	"time"
 )
 
+// ClusterMaxZoom is the highest zoom level at which pins are clustered.
+// Above it, pins are returned at the exact location of their events.
+const ClusterMaxZoom = 11
+
+// PinsLimit is the maximum amount of events, or of clusters of a kind, that pins are built from.
+// Pins are truncated above it, and reported so.
+const PinsLimit = 5000
+
+// clusterCellsPerTile is the number of grid cells per 256px map tile side,
+// so that a cluster covers roughly 64px on screen at any zoom level
+const clusterCellsPerTile = 4
+
 // Pin represents a pin on the map
 // It contains the location, kind and amount of events at that location
 // It has two goals:
 //  1. Reduce the size of payloads sent to the client (compared to sending all events)
 //  2. Display events which are in the same place in one pin instead of a stack of pins
+//
+// When pins are clustered, Loc is the centroid of the clustered events,
+// Kind is the most represented kind and Kinds holds the amount of events per kind.
+//
+// NextBegin is the earliest begin of its events which have not begun at the start of the requested window,
+// nil when all of them are already in progress.
 type Pin struct {
-	Loc    EventLocation `json:"loc"`
-	Kind   Kind          `json:"kind"`
-	Amount int           `json:"amount"`
+	Loc       EventLocation `json:"loc"`
+	Kind      Kind          `json:"kind"`
+	Amount    int           `json:"amount"`
+	Kinds     map[Kind]int  `json:"kinds,omitempty"`
+	NextBegin *time.Time    `json:"next_begin,omitempty"`
 }
 
+// PinsService returns the pins in bounds, along with whether they are truncated to PinsLimit
 type PinsService interface {
-	GetPins(bounds Bounds, maxDate time.Time) ([]Pin, error)
+	GetPins(bounds Bounds, window TimeWindow, filter EventFilter) ([]Pin, bool, error)
+	GetClusters(bounds Bounds, window TimeWindow, zoom int, filter EventFilter) ([]Pin, bool, error)
 }
 
 type pins struct {
@@ -32,11 +55,12 @@ func NewPins(eventRepository EventRepository) PinsService {
 	}
 }
 
-func (p *pins) GetPins(bounds Bounds, maxDate time.Time) ([]Pin, error) {
-	events, err := p.eventRepository.ByBoundsAndMaxDate(bounds, maxDate)
+func (p *pins) GetPins(bounds Bounds, window TimeWindow, filter EventFilter) ([]Pin, bool, error) {
+	events, err := p.eventRepository.ByBoundsAndTimeWindow(bounds, window, filter, PinsLimit+1)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	events, truncated := truncatePins(events)
 
 	pinsMap := make(map[string]Pin)
 
@@ -47,11 +71,13 @@ func (p *pins) GetPins(bounds Bounds, maxDate time.Time) ([]Pin, error) {
 		pin, exists := pinsMap[key]
 		if exists {
 			pin.Amount++
+			pin.NextBegin = earliest(pin.NextBegin, event.NextBegin)
 		} else {
 			pin = Pin{
-				Loc:    event.Loc,
-				Kind:   event.Kind,
-				Amount: 1,
+				Loc:       event.Loc,
+				Kind:      event.Kind,
+				Amount:    1,
+				NextBegin: event.NextBegin,
 			}
 		}
 
@@ -67,7 +93,105 @@ func (p *pins) GetPins(bounds Bounds, maxDate time.Time) ([]Pin, error) {
 		return cmp.Compare(getLocationKindKey(a.Loc, a.Kind), getLocationKindKey(b.Loc, b.Kind))
 	})
 
-	return pins, nil
+	return pins, truncated, nil
+}
+
+// GetClusters groups events of a same grid cell together, the cell size depending on the zoom level.
+// It keeps the payload size bounded by the amount of cells in the bounds, whatever the amount of events.
+func (p *pins) GetClusters(bounds Bounds, window TimeWindow, zoom int, filter EventFilter) ([]Pin, bool, error) {
+	if zoom > ClusterMaxZoom {
+		return p.GetPins(bounds, window, filter)
+	}
+
+	cellSize := ClusterCellSize(zoom)
+	kindPins, err := p.eventRepository.ClustersByBoundsAndTimeWindow(bounds, window, cellSize, filter, PinsLimit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	kindPins, truncated := truncatePins(kindPins)
+
+	type cluster struct {
+		latSum float64
+		lonSum float64
+		pin    Pin
+	}
+	clustersMap := make(map[[2]int64]*cluster)
+
+	// merge the pins of each kind in the same cell into a single pin
+	for _, kindPin := range kindPins {
+		key := gridCell(kindPin.Loc, cellSize)
+
+		c, exists := clustersMap[key]
+		if !exists {
+			c = &cluster{pin: Pin{Kinds: make(map[Kind]int)}}
+			clustersMap[key] = c
+		}
+
+		c.latSum += kindPin.Loc.Lat * float64(kindPin.Amount)
+		c.lonSum += kindPin.Loc.Lon * float64(kindPin.Amount)
+		c.pin.Amount += kindPin.Amount
+		c.pin.Kinds[kindPin.Kind] += kindPin.Amount
+		c.pin.NextBegin = earliest(c.pin.NextBegin, kindPin.NextBegin)
+	}
+
+	pins := make([]Pin, 0, len(clustersMap))
+	for _, c := range clustersMap {
+		c.pin.Loc = EventLocation{
+			Lat: c.latSum / float64(c.pin.Amount),
+			Lon: c.lonSum / float64(c.pin.Amount),
+		}
+		c.pin.Kind = dominantKind(c.pin.Kinds)
+		pins = append(pins, c.pin)
+	}
+
+	slices.SortFunc(pins, func(a, b Pin) int {
+		return cmp.Compare(getLocationKindKey(a.Loc, a.Kind), getLocationKindKey(b.Loc, b.Kind))
+	})
+
+	return pins, truncated, nil
+}
+
+// truncatePins keeps the first PinsLimit pins, read from the repository with one more to know whether there were more
+func truncatePins(pins []Pin) ([]Pin, bool) {
+	if len(pins) > PinsLimit {
+		return pins[:PinsLimit], true
+	}
+	return pins, false
+}
+
+// ClusterCellSize returns the side, in degrees, of the grid cells used to cluster pins at a zoom level
+func ClusterCellSize(zoom int) float64 {
+	return 360 / (math.Exp2(float64(zoom)) * clusterCellsPerTile)
+}
+
+// gridCell returns the coordinates of the grid cell containing a location.
+// Locations are shifted to positive values so that truncation matches the one done in SQL.
+func gridCell(loc EventLocation, cellSize float64) [2]int64 {
+	return [2]int64{
+		int64((loc.Lat + 90) / cellSize),
+		int64((loc.Lon + 180) / cellSize),
+	}
+}
+
+// dominantKind returns the kind with the most events, ties are broken alphabetically
+func dominantKind(kinds map[Kind]int) Kind {
+	dominant := KindUnknown
+	maxAmount := 0
+	for kind, amount := range kinds {
+		if amount > maxAmount || (amount == maxAmount && kind < dominant) {
+			dominant = kind
+			maxAmount = amount
+		}
+	}
+	return dominant
+}
+
+// earliest returns the earliest of two optional times
+func earliest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.Before(*a)) {
+		return b
+	}
+	return a
 }
 
 // getLocationKindKey creates a unique key for a location and kind combination
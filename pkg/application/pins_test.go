@@ -18,17 +18,17 @@ func TestGetPinsError(t *testing.T) {
 	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
 
 	mockEventRepo.EXPECT().
-		ByBoundsAndMaxDate(gomock.Any(), gomock.Any()).
+		ByBoundsAndTimeWindow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil, errors.New("error"))
 
 	pinsService := application.NewPins(mockEventRepo)
 
-	_, err := pinsService.GetPins(application.Bounds{
+	_, _, err := pinsService.GetPins(application.Bounds{
 		North: 48.9,
 		South: 48.8,
 		East:  2.4,
 		West:  2.3,
-	}, time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC))
+	}, application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}, application.EventFilter{})
 
 	if err == nil {
 		t.Errorf("Expected error, got nil")
@@ -38,7 +38,7 @@ func TestGetPinsError(t *testing.T) {
 func TestGetPinsSuccess(t *testing.T) {
 	testCases := map[string]struct {
 		bounds       application.Bounds
-		maxDate      time.Time
+		window       application.TimeWindow
 		pinsReturned []application.Pin
 		expected     []application.Pin
 	}{
@@ -49,7 +49,7 @@ func TestGetPinsSuccess(t *testing.T) {
 				East:  2.4,
 				West:  2.3,
 			},
-			maxDate:      time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC),
+			window:       application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)},
 			pinsReturned: []application.Pin{},
 			expected:     []application.Pin{},
 		},
@@ -60,7 +60,7 @@ func TestGetPinsSuccess(t *testing.T) {
 				East:  2.4,
 				West:  2.3,
 			},
-			maxDate: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC),
+			window: application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)},
 			pinsReturned: []application.Pin{
 				{
 					Loc:    application.EventLocation{Lat: 48.8, Lon: 2.3},
@@ -83,7 +83,7 @@ func TestGetPinsSuccess(t *testing.T) {
 				East:  2.4,
 				West:  2.3,
 			},
-			maxDate: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC),
+			window: application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)},
 			pinsReturned: []application.Pin{
 				{
 					Loc:    application.EventLocation{Lat: 48.8, Lon: 2.3},
@@ -116,7 +116,7 @@ func TestGetPinsSuccess(t *testing.T) {
 				East:  2.4,
 				West:  2.3,
 			},
-			maxDate: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC),
+			window: application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)},
 			pinsReturned: []application.Pin{
 				{
 					Loc:    application.EventLocation{Lat: 48.8, Lon: 2.3},
@@ -157,11 +157,11 @@ func TestGetPinsSuccess(t *testing.T) {
 			mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
 
 			mockEventRepo.EXPECT().
-				ByBoundsAndMaxDate(tc.bounds, tc.maxDate).
+				ByBoundsAndTimeWindow(tc.bounds, tc.window, application.EventFilter{}, application.PinsLimit+1).
 				Return(tc.pinsReturned, nil)
 
 			pinsService := application.NewPins(mockEventRepo)
-			pins, err := pinsService.GetPins(tc.bounds, tc.maxDate)
+			pins, _, err := pinsService.GetPins(tc.bounds, tc.window, application.EventFilter{})
 			if err != nil {
 				t.Fatalf("failed to get pins: %v", err)
 			}
@@ -171,3 +171,236 @@ func TestGetPinsSuccess(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClustersError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	mockEventRepo.EXPECT().
+		ClustersByBoundsAndTimeWindow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("error"))
+
+	pinsService := application.NewPins(mockEventRepo)
+
+	_, _, err := pinsService.GetClusters(application.Bounds{
+		North: 51.1,
+		South: 41.3,
+		East:  9.6,
+		West:  -5.2,
+	}, application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}, 5, application.EventFilter{})
+
+	if err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestGetClustersSuccess(t *testing.T) {
+	bounds := application.Bounds{
+		North: 51.1,
+		South: 41.3,
+		East:  9.6,
+		West:  -5.2,
+	}
+	window := application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}
+
+	testCases := map[string]struct {
+		zoom         int
+		pinsReturned []application.Pin
+		expected     []application.Pin
+	}{
+		"without events": {
+			zoom:         5,
+			pinsReturned: []application.Pin{},
+			expected:     []application.Pin{},
+		},
+		"with 2 kinds in the same cell": {
+			zoom: 5,
+			pinsReturned: []application.Pin{
+				{
+					Loc:    application.EventLocation{Lat: 48.5, Lon: 2.5},
+					Kind:   application.KindConcert,
+					Amount: 3,
+				},
+				{
+					Loc:    application.EventLocation{Lat: 48.75, Lon: 2.75},
+					Kind:   application.KindTheater,
+					Amount: 1,
+				},
+			},
+			expected: []application.Pin{
+				{
+					Loc:    application.EventLocation{Lat: 48.5625, Lon: 2.5625},
+					Kind:   application.KindConcert,
+					Amount: 4,
+					Kinds: map[application.Kind]int{
+						application.KindConcert: 3,
+						application.KindTheater: 1,
+					},
+				},
+			},
+		},
+		"with 2 cells": {
+			zoom: 5,
+			pinsReturned: []application.Pin{
+				{
+					Loc:    application.EventLocation{Lat: 48.5, Lon: 2.5},
+					Kind:   application.KindConcert,
+					Amount: 3,
+				},
+				{
+					Loc:    application.EventLocation{Lat: 43.25, Lon: 5.5},
+					Kind:   application.KindConcert,
+					Amount: 2,
+				},
+			},
+			expected: []application.Pin{
+				{
+					Loc:    application.EventLocation{Lat: 43.25, Lon: 5.5},
+					Kind:   application.KindConcert,
+					Amount: 2,
+					Kinds:  map[application.Kind]int{application.KindConcert: 2},
+				},
+				{
+					Loc:    application.EventLocation{Lat: 48.5, Lon: 2.5},
+					Kind:   application.KindConcert,
+					Amount: 3,
+					Kinds:  map[application.Kind]int{application.KindConcert: 3},
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+			mockEventRepo.EXPECT().
+				ClustersByBoundsAndTimeWindow(bounds, window, application.ClusterCellSize(tc.zoom), application.EventFilter{}, application.PinsLimit+1).
+				Return(tc.pinsReturned, nil)
+
+			pinsService := application.NewPins(mockEventRepo)
+			pins, _, err := pinsService.GetClusters(bounds, window, tc.zoom, application.EventFilter{})
+			if err != nil {
+				t.Fatalf("failed to get clusters: %v", err)
+			}
+			if !reflect.DeepEqual(pins, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, pins)
+			}
+		})
+	}
+}
+
+func TestGetClustersAboveMaxZoom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	mockEventRepo.EXPECT().
+		ByBoundsAndTimeWindow(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]application.Pin{}, nil)
+
+	pinsService := application.NewPins(mockEventRepo)
+
+	_, _, err := pinsService.GetClusters(application.Bounds{
+		North: 48.9,
+		South: 48.8,
+		East:  2.4,
+		West:  2.3,
+	}, application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}, application.ClusterMaxZoom+1, application.EventFilter{})
+	if err != nil {
+		t.Fatalf("failed to get clusters: %v", err)
+	}
+}
+
+func TestPinsKeepEarliestNextBegin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	soon := time.Date(2025, 11, 24, 10, 0, 0, 0, time.UTC)
+	later := time.Date(2025, 11, 24, 20, 0, 0, 0, time.UTC)
+	loc := application.EventLocation{Lat: 48.5, Lon: 2.5}
+	window := application.TimeWindow{From: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 11, 25, 0, 0, 0, 0, time.UTC)}
+
+	// an event in progress has no next begin
+	mockEventRepo.EXPECT().
+		ByBoundsAndTimeWindow(gomock.Any(), window, gomock.Any(), gomock.Any()).
+		Return([]application.Pin{
+			{Loc: loc, Kind: application.KindConcert, Amount: 1, NextBegin: &later},
+			{Loc: loc, Kind: application.KindConcert, Amount: 1},
+			{Loc: loc, Kind: application.KindConcert, Amount: 1, NextBegin: &soon},
+		}, nil)
+
+	mockEventRepo.EXPECT().
+		ClustersByBoundsAndTimeWindow(gomock.Any(), window, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]application.Pin{
+			{Loc: loc, Kind: application.KindConcert, Amount: 2, NextBegin: &later},
+			{Loc: loc, Kind: application.KindTheater, Amount: 1, NextBegin: &soon},
+			{Loc: loc, Kind: application.KindMovie, Amount: 1},
+		}, nil)
+
+	pinsService := application.NewPins(mockEventRepo)
+	bounds := application.Bounds{North: 49, South: 48, East: 3, West: 2}
+
+	pins, _, err := pinsService.GetPins(bounds, window, application.EventFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pins) != 1 || pins[0].NextBegin == nil || !pins[0].NextBegin.Equal(soon) {
+		t.Errorf("Expected a single pin beginning at %v, got %v", soon, pins)
+	}
+
+	clusters, _, err := pinsService.GetClusters(bounds, window, 5, application.EventFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].NextBegin == nil || !clusters[0].NextBegin.Equal(soon) {
+		t.Errorf("Expected a single cluster beginning at %v, got %v", soon, clusters)
+	}
+}
+
+func TestGetPinsTruncated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEventRepo := applicationmocks.NewMockEventRepository(ctrl)
+
+	events := make([]application.Pin, application.PinsLimit+1)
+	for i := range events {
+		events[i] = application.Pin{Loc: application.EventLocation{Lat: 48, Lon: float64(i) / 10000}, Kind: application.KindConcert, Amount: 1}
+	}
+
+	mockEventRepo.EXPECT().
+		ByBoundsAndTimeWindow(gomock.Any(), gomock.Any(), gomock.Any(), application.PinsLimit+1).
+		Return(events, nil)
+	mockEventRepo.EXPECT().
+		ByBoundsAndTimeWindow(gomock.Any(), gomock.Any(), gomock.Any(), application.PinsLimit+1).
+		Return(events[:application.PinsLimit], nil)
+
+	pinsService := application.NewPins(mockEventRepo)
+	bounds := application.Bounds{North: 49, South: 47, East: 1, West: 0}
+	window := application.TimeWindow{To: time.Date(2025, 11, 24, 0, 0, 0, 0, time.UTC)}
+
+	pins, truncated, err := pinsService.GetPins(bounds, window, application.EventFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !truncated || len(pins) != application.PinsLimit {
+		t.Errorf("Expected %d truncated pins, got %d (truncated: %v)", application.PinsLimit, len(pins), truncated)
+	}
+
+	pins, truncated, err = pinsService.GetPins(bounds, window, application.EventFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if truncated || len(pins) != application.PinsLimit {
+		t.Errorf("Expected %d pins not truncated, got %d (truncated: %v)", application.PinsLimit, len(pins), truncated)
+	}
+}
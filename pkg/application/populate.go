@@ -1,29 +1,173 @@
 package application
 
-import "log/slog"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
 
 type EventSaver interface {
-	SaveEvents(events []Event) error
+	// SaveEvents saves a batch of events, reporting what happened to each of them.
+	// An error is returned when the batch could not be processed.
+	SaveEvents(ctx context.Context, events []Event) (SaveReport, error)
+}
+
+type Populator interface {
+	Populate(ctx context.Context, location CollectLocation) error
+	PopulateLocations(ctx context.Context, iterator LocationsIterator, limit int) (CollectionRun, error)
 }
 
 type populator struct {
 	collector  Collector
 	eventSaver EventSaver
+	// cursors is nil when the collects are not incremental
+	cursors CursorRepository
+	// full ignores the stored cursors, the cursors returned by the collectors replacing them
+	full bool
 }
 
-func NewPopulator(collector Collector, eventSaver EventSaver) populator {
-	return populator{
+func NewPopulator(collector Collector, eventSaver EventSaver) Populator {
+	return &populator{
 		collector:  collector,
 		eventSaver: eventSaver,
 	}
 }
-func (c *populator) Populate(location CollectLocation) error {
+
+// NewIncrementalPopulator lets the incremental collectors only collect what is new or modified since the cursors
+// stored in the repository for each location and source, all the events being collected again if full is true
+func NewIncrementalPopulator(collector Collector, eventSaver EventSaver, cursors CursorRepository, full bool) Populator {
+	return &populator{
+		collector:  collector,
+		eventSaver: eventSaver,
+		cursors:    cursors,
+		full:       full,
+	}
+}
+
+func (c *populator) Populate(ctx context.Context, location CollectLocation) error {
+	_, err := c.populate(ctx, location)
+	return err
+}
+
+// populate collects and saves the events of the location, reporting what happened
+func (c *populator) populate(ctx context.Context, location CollectLocation) (LocationRun, error) {
+	run := LocationRun{City: location.City}
+
+	var cursors *SourceCursors
+	if c.cursors != nil {
+		stored := map[string]string{}
+		if !c.full {
+			var err error
+			stored, err = c.cursors.Cursors(ctx, location.City)
+			if err != nil {
+				return run, fmt.Errorf("failed to get cursors: %w", err)
+			}
+		}
+		cursors = NewSourceCursors(stored)
+		ctx = WithSourceCursors(ctx, cursors)
+	}
+
 	slog.Info("Collecting events", "city", location.City)
-	events, err := c.collector.Collect(location)
+	events, report, err := c.collect(ctx, location)
+	run.Collectors = newCollectorRuns(report)
 	if err != nil {
-		return err
+		return run, err
 	}
 
 	slog.Info("Saving events", "count", len(events))
-	return c.eventSaver.SaveEvents(events)
+	run.Events = len(events)
+	saveReport, err := c.eventSaver.SaveEvents(ctx, events)
+	run.Inserted = saveReport.Inserted
+	run.Updated = saveReport.Updated
+	run.Unchanged = saveReport.Unchanged
+	run.Skipped = saveReport.Skipped
+	run.Failed = saveReport.Failed
+	if err != nil {
+		return run, err
+	}
+
+	slog.Info("Events saved", "city", location.City, "inserted", saveReport.Inserted, "updated", saveReport.Updated, "unchanged", saveReport.Unchanged, "skipped", saveReport.Skipped, "failed", saveReport.Failed)
+	for _, rejected := range saveReport.Rejected {
+		if rejected.Status == SaveStatusFailed {
+			slog.Warn("Failed to save event", "city", location.City, "event", events[rejected.Index].Name, "reason", rejected.Reason)
+		}
+	}
+
+	// Cursors only advance once all the events are saved, so that the failed ones are collected again
+	if cursors != nil && saveReport.Failed == 0 {
+		if changed := cursors.Changed(); len(changed) > 0 {
+			if err := c.cursors.SaveCursors(ctx, location.City, changed); err != nil {
+				slog.Warn("Failed to save cursors", "city", location.City, "error", err)
+			}
+		}
+	}
+	return run, nil
+}
+
+// PopulateLocations populates events for the locations of the iterator, up to limit locations (no limit if 0).
+// A location failing does not stop the run, the outcome of every location is returned in the run.
+func (c *populator) PopulateLocations(ctx context.Context, iterator LocationsIterator, limit int) (CollectionRun, error) {
+	run := CollectionRun{StartedAt: time.Now(), Locations: []LocationRun{}}
+
+	for limit <= 0 || run.Succeeded() < limit {
+		if err := ctx.Err(); err != nil {
+			run.Error = err.Error()
+			run.EndedAt = time.Now()
+			return run, err
+		}
+
+		location := iterator.Next()
+		if location == nil {
+			slog.Info("No more locations available")
+			break
+		}
+
+		slog.Info("Processing location", "city", location.City)
+
+		locationRun, err := c.populate(ctx, *location)
+		if err != nil {
+			locationRun.Error = err.Error()
+			run.Locations = append(run.Locations, locationRun)
+			slog.Error("Failed to populate events", "city", location.City, "error", err)
+			continue
+		}
+
+		run.Locations = append(run.Locations, locationRun)
+		slog.Info("Events populated successfully", "city", location.City)
+
+		if tracker, ok := iterator.(LocationsTracker); ok {
+			if err := tracker.MarkCollected(ctx, *location); err != nil {
+				slog.Warn("Failed to mark location as collected", "city", location.City, "error", err)
+			}
+		}
+	}
+
+	run.EndedAt = time.Now()
+	for _, collector := range run.Silent() {
+		slog.Warn("Collector collected no events", "collector", collector, "locations", len(run.Locations))
+	}
+	return run, nil
+}
+
+// collect runs the collector, logging the failing sources when the collector reports them
+func (c *populator) collect(ctx context.Context, location CollectLocation) ([]Event, CollectReport, error) {
+	reportingCollector, ok := c.collector.(ReportingCollector)
+	if !ok {
+		startedAt := time.Now()
+		events, err := c.collector.Collect(ctx, location)
+		report := CollectReport{Location: location, Collectors: []CollectorReport{{Collector: "collector", Events: len(events), Duration: time.Since(startedAt), Err: err}}}
+		if err != nil && len(events) > 0 {
+			// The collector partially failed, its events are saved and its error is reported
+			slog.Warn("Collector failed", "city", location.City, "collector", "collector", "error", err)
+			return events, report, nil
+		}
+		return events, report, err
+	}
+
+	events, report, err := reportingCollector.CollectWithReport(ctx, location)
+	for _, failed := range report.Failed() {
+		slog.Warn("Collector failed", "city", location.City, "collector", failed.Collector, "duration", failed.Duration, "error", failed.Err)
+	}
+	return events, report, err
 }
@@ -0,0 +1,51 @@
+package application
+
+type SaveStatus string
+
+const (
+	SaveStatusInserted  SaveStatus = "inserted"
+	SaveStatusUpdated   SaveStatus = "updated"
+	SaveStatusUnchanged SaveStatus = "unchanged" // Event already saved as is
+	SaveStatusSkipped   SaveStatus = "skipped"   // Invalid event, not saved
+	SaveStatusFailed    SaveStatus = "failed"    // Valid event which could not be saved
+)
+
+// EventSaveResult is the outcome of saving the event at Index of a batch
+type EventSaveResult struct {
+	Index  int        `json:"index"`
+	Status SaveStatus `json:"status"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// SaveReport tells what actually landed when saving a batch of events.
+// Only skipped and failed events are listed in Rejected, with the reason why.
+type SaveReport struct {
+	Inserted  int               `json:"inserted"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Rejected  []EventSaveResult `json:"rejected"`
+}
+
+func NewSaveReport() SaveReport {
+	return SaveReport{Rejected: []EventSaveResult{}}
+}
+
+// Add counts the result in the report
+func (r *SaveReport) Add(result EventSaveResult) {
+	switch result.Status {
+	case SaveStatusInserted:
+		r.Inserted++
+	case SaveStatusUpdated:
+		r.Updated++
+	case SaveStatusUnchanged:
+		r.Unchanged++
+	case SaveStatusSkipped:
+		r.Skipped++
+		r.Rejected = append(r.Rejected, result)
+	case SaveStatusFailed:
+		r.Failed++
+		r.Rejected = append(r.Rejected, result)
+	}
+}
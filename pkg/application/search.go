@@ -0,0 +1,33 @@
+package application
+
+import (
+	"errors"
+	"strings"
+)
+
+// SearchLimit is the maximum amount of events returned by a search
+const SearchLimit = 50
+
+var ErrEmptySearch = errors.New("empty search")
+
+// SearchQuery looks for events by name, place or genre, ignoring case and accents
+type SearchQuery struct {
+	Text string
+	// Bounds restricts the search to an area, events are searched everywhere if nil
+	Bounds *Bounds
+	Window TimeWindow
+}
+
+// Terms returns the normalized words of the searched text
+func (q SearchQuery) Terms() []string {
+	return strings.Fields(NormalizeTitle(q.Text))
+}
+
+// SearchResult is an event matching a search
+type SearchResult struct {
+	EventRecord
+	// Snippet is an HTML escaped extract of the event where the searched terms are surrounded by <mark> tags
+	Snippet string
+	// Rank orders results from the most relevant, lower is better
+	Rank float64
+}
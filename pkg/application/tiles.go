@@ -0,0 +1,86 @@
+package application
+
+import (
+	"fmt"
+	"math"
+)
+
+// TileExtent is the amount of units per side of a tile in which pins are located
+const TileExtent = 4096
+
+// TileMaxZoom is the highest zoom level at which tiles are served
+const TileMaxZoom = 22
+
+// Tile is a web mercator map tile, as requested by map libraries
+type Tile struct {
+	Z int
+	X int
+	Y int
+}
+
+func (t Tile) Validate() error {
+	if t.Z < 0 || t.Z > TileMaxZoom {
+		return fmt.Errorf("zoom must be between 0 and %d, got %d", TileMaxZoom, t.Z)
+	}
+
+	n := 1 << t.Z
+	if t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return fmt.Errorf("tile %d/%d does not exist at zoom %d", t.X, t.Y, t.Z)
+	}
+
+	return nil
+}
+
+// Bounds returns the area covered by the tile
+func (t Tile) Bounds() Bounds {
+	n := math.Exp2(float64(t.Z))
+	return Bounds{
+		North: tileLat(float64(t.Y), n),
+		South: tileLat(float64(t.Y+1), n),
+		East:  float64(t.X+1)/n*360 - 180,
+		West:  float64(t.X)/n*360 - 180,
+	}
+}
+
+// Project returns the position of a location in the tile, from its top left corner, in TileExtent units
+func (t Tile) Project(loc EventLocation) (x, y int) {
+	n := math.Exp2(float64(t.Z))
+	lat := loc.Lat * math.Pi / 180
+
+	tileX := (loc.Lon + 180) / 360 * n
+	tileY := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+
+	return int(math.Round((tileX - float64(t.X)) * TileExtent)), int(math.Round((tileY - float64(t.Y)) * TileExtent))
+}
+
+// tileLat returns the latitude of the top edge of the tiles of row y, among n rows
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+type TilesService interface {
+	// GetTile returns the pins of a tile, clustered according to its zoom level, and whether they are truncated
+	GetTile(tile Tile, window TimeWindow, filter EventFilter) ([]Pin, bool, error)
+	// Version changes whenever events are saved or deleted, so that tiles can be cached until then
+	Version() (int64, error)
+}
+
+type tiles struct {
+	pinsService     PinsService
+	eventRepository EventRepository
+}
+
+func NewTiles(pinsService PinsService, eventRepository EventRepository) TilesService {
+	return &tiles{
+		pinsService:     pinsService,
+		eventRepository: eventRepository,
+	}
+}
+
+func (t *tiles) GetTile(tile Tile, window TimeWindow, filter EventFilter) ([]Pin, bool, error) {
+	return t.pinsService.GetClusters(tile.Bounds(), window, tile.Z, filter)
+}
+
+func (t *tiles) Version() (int64, error) {
+	return t.eventRepository.Version()
+}
@@ -0,0 +1,71 @@
+package application_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+)
+
+func TestTileValidate(t *testing.T) {
+	testCases := map[string]struct {
+		tile    application.Tile
+		isValid bool
+	}{
+		"world":          {tile: application.Tile{Z: 0, X: 0, Y: 0}, isValid: true},
+		"paris":          {tile: application.Tile{Z: 12, X: 2074, Y: 1408}, isValid: true},
+		"negative zoom":  {tile: application.Tile{Z: -1}},
+		"zoom too high":  {tile: application.Tile{Z: application.TileMaxZoom + 1}},
+		"x out of range": {tile: application.Tile{Z: 1, X: 2, Y: 0}},
+		"negative y":     {tile: application.Tile{Z: 1, X: 0, Y: -1}},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := testCase.tile.Validate()
+			if testCase.isValid && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if !testCase.isValid && err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestTileBounds(t *testing.T) {
+	// web mercator stops at ~85.0511° so that the world is a square
+	world := application.Tile{Z: 0, X: 0, Y: 0}.Bounds()
+	if world.West != -180 || world.East != 180 || math.Abs(world.North-85.0511) > 0.0001 || math.Abs(world.South+85.0511) > 0.0001 {
+		t.Errorf("Expected the whole world, got %+v", world)
+	}
+
+	northEast := application.Tile{Z: 1, X: 1, Y: 0}.Bounds()
+	if northEast.West != 0 || northEast.East != 180 || northEast.South != 0 {
+		t.Errorf("Expected the north east quarter of the world, got %+v", northEast)
+	}
+}
+
+func TestTileProject(t *testing.T) {
+	tile := application.Tile{Z: 12, X: 2074, Y: 1408}
+	bounds := tile.Bounds()
+
+	testCases := map[string]struct {
+		loc  application.EventLocation
+		x, y int
+	}{
+		"top left":     {loc: application.EventLocation{Lat: bounds.North, Lon: bounds.West}, x: 0, y: 0},
+		"bottom right": {loc: application.EventLocation{Lat: bounds.South, Lon: bounds.East}, x: application.TileExtent, y: application.TileExtent},
+		// latitudes are stretched towards the poles, the middle latitude is below the center of the tile
+		"middle": {loc: application.EventLocation{Lat: (bounds.North + bounds.South) / 2, Lon: (bounds.West + bounds.East) / 2}, x: application.TileExtent / 2, y: 2049},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			x, y := tile.Project(testCase.loc)
+			if x != testCase.x || y != testCase.y {
+				t.Errorf("Expected (%d, %d), got (%d, %d)", testCase.x, testCase.y, x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package application
+
+import (
+	"errors"
+	"time"
+)
+
+// TimeWindow is a period of time, an event happens during it when their periods overlap
+type TimeWindow struct {
+	From time.Time
+	// To is the end of the window, which is open-ended when To is zero
+	To time.Time
+}
+
+func (w TimeWindow) Validate() error {
+	if !w.To.IsZero() && w.To.Before(w.From) {
+		return errors.New("window ends before it begins")
+	}
+	return nil
+}
@@ -7,31 +7,216 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
 )
 
 type pbClient struct {
 	baseURL string
+	apiKey  string
 }
 
-func NewPBClient(baseURL string) *pbClient {
+// NewPBClient creates a client for the sortir.in server at baseURL,
+// apiKey is sent to authenticate the ingestion requests
+func NewPBClient(baseURL string, apiKey string) *pbClient {
 	return &pbClient{
 		baseURL: baseURL,
+		apiKey:  apiKey,
 	}
 }
 
-func (c *pbClient) SaveEvents(events []application.Event) error {
+func (c *pbClient) SaveEvents(ctx context.Context, events []application.Event) (application.SaveReport, error) {
 	jsonData, err := json.Marshal(events)
+	if err != nil {
+		return application.SaveReport{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/api/events", c.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return application.SaveReport{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return application.SaveReport{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return application.SaveReport{}, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return application.SaveReport{}, fmt.Errorf("request failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	var report application.SaveReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return application.SaveReport{}, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return report, nil
+}
+
+type locationResponse struct {
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Radius float64 `json:"radius"`
+}
+
+func (c *pbClient) EnabledLocations(ctx context.Context) ([]application.CollectLocation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/locations", c.baseURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return nil, fmt.Errorf("request failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	var locations []locationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&locations); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	collectLocations := make([]application.CollectLocation, len(locations))
+	for i, location := range locations {
+		collectLocations[i] = application.CollectLocation{City: location.Name, Lat: location.Lat, Lon: location.Lon, Radius: location.Radius}
+	}
+	return collectLocations, nil
+}
+
+func (c *pbClient) MarkCollected(ctx context.Context, city string, at time.Time) error {
+	jsonData, err := json.Marshal(map[string]time.Time{"at": at})
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, fmt.Sprintf("%s/api/events", c.baseURL), bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/api/locations/%s/collected", c.baseURL, url.PathEscape(city)), bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return application.ErrLocationNotFound
+	}
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		return fmt.Errorf("request failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (c *pbClient) SaveRun(ctx context.Context, run application.CollectionRun) error {
+	jsonData, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/collection_runs", c.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		return fmt.Errorf("request failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+func (c *pbClient) Cursors(ctx context.Context, city string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/locations/%s/cursors", c.baseURL, url.PathEscape(city)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return nil, fmt.Errorf("request failed with status code: %d and body: %s", resp.StatusCode, string(body))
+	}
+
+	var cursors map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&cursors); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return cursors, nil
+}
+
+func (c *pbClient) SaveCursors(ctx context.Context, city string, cursors map[string]string) error {
+	jsonData, err := json.Marshal(cursors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/api/locations/%s/cursors", c.baseURL, url.PathEscape(city)), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
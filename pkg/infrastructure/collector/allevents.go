@@ -2,9 +2,12 @@ package collector
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,90 +15,83 @@ import (
 	"github.com/leorolland/sortir.in/pkg/application"
 )
 
+// allEventsCategories are the categories queried by default
+var allEventsCategories = []string{"music", "parties", "entertainment", "art", "food-drinks", "business", "sports", "exhibitions", "health-wellness", "workshops", "lgbt-pride", "theatre"}
+
+// allEventsBaseURL is the default base URL of the allevents APIs
+const allEventsBaseURL = "https://allevents.in"
+
 type allEventsCollector struct {
-	client *http.Client
+	client     *http.Client
+	baseURL    string
+	categories []string
+	// radius is the distance searched around the location by the mobile API, in kilometers
+	radius float64
+	// rows is the amount of events requested per category
+	rows   int
+	window time.Duration
 }
 
 func NewAllEventsCollector() application.Collector {
+	return newAllEventsCollector(Options{})
+}
+
+func newAllEventsCollector(options Options) application.Collector {
+	categories := options.Categories
+	if len(categories) == 0 {
+		categories = allEventsCategories
+	}
+
 	return &allEventsCollector{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:     options.client(),
+		baseURL:    or(options.BaseURL, allEventsBaseURL),
+		categories: categories,
+		radius:     or(options.Radius, 50),
+		rows:       or(options.PageSize, 1000),
+		window:     or(time.Duration(options.Window), 15*24*time.Hour),
 	}
 }
 
-func (c *allEventsCollector) Collect(location application.CollectLocation) ([]application.Event, error) {
+// Collect queries the mobile API then each category. The events of the successful queries are returned along with
+// the errors of the failed ones, the collect only fails without events if all the queries fail.
+func (c *allEventsCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	errs := []error{}
+
 	// First collect events from the mobile API
-	var mobileQueryEvents []application.Event
-	mEvents, err := c.mobileQueryCollect(location)
+	mobileQueryEvents, err := c.mobileQueryCollect(ctx, location)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		slog.Warn("error collecting events from mobile API", "error", err)
+		errs = append(errs, fmt.Errorf("mobile API: %w", err))
 	} else {
-		slog.Info("Collecting allevents from mobile API", "city", location.City, "found", len(mEvents))
-		mobileQueryEvents = mEvents
+		slog.Info("Collecting allevents from mobile API", "city", location.City, "found", len(mobileQueryEvents))
 	}
 
 	// Then collect events from the category API
-	categories := []string{"music", "parties", "entertainment", "art", "food-drinks", "business", "sports", "exhibitions", "health-wellness", "workshops", "lgbt-pride", "theatre"}
 	var categoryQueryEvents []application.Event
 
-	for _, category := range categories {
-		events, err := c.categoryQueryCollect(location, category)
+	for _, category := range c.categories {
+		events, err := c.categoryQueryCollect(ctx, location, category)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		if err != nil {
 			slog.Warn("error collecting events for category", "category", category, "error", err)
+			errs = append(errs, fmt.Errorf("category %s: %w", category, err))
 			continue
 		}
 		slog.Info("Collecting allevents by category", "city", location.City, "category", category, "found", len(events))
 		categoryQueryEvents = append(categoryQueryEvents, events...)
 	}
 
-	// Merge results, with category events taking precedence (acting as upsert)
-	allEvents := mergeMobileAndCategoryQueryEvents(mobileQueryEvents, categoryQueryEvents)
-
-	return removeDuplicateEvents(allEvents), nil
-}
-
-// removeDuplicateEvents removes duplicate events based on event name
-func removeDuplicateEvents(events []application.Event) []application.Event {
-	uniqueEvents := make([]application.Event, 0, len(events))
-	seen := make(map[string]bool)
-
-	for _, event := range events {
-		if _, exists := seen[event.Name]; !exists {
-			seen[event.Name] = true
-			uniqueEvents = append(uniqueEvents, event)
-		}
-	}
-
-	return uniqueEvents
-}
-
-// mergeMobileAndCategoryQueryEvents merges events from mobile and category APIs
-// with category events taking precedence when there are duplicates
-func mergeMobileAndCategoryQueryEvents(mobileQueryEvents, categoryQueryEvents []application.Event) []application.Event {
-	// Create a map of event names to events from the mobile API
-	mobileQueryEventMap := make(map[string]application.Event)
-	for _, event := range mobileQueryEvents {
-		mobileQueryEventMap[event.Name] = event
+	if len(errs) == len(c.categories)+1 {
+		return nil, fmt.Errorf("all queries failed: %w", errors.Join(errs...))
 	}
 
-	// Add all category events to the result
-	result := make([]application.Event, len(categoryQueryEvents))
-	copy(result, categoryQueryEvents)
-
-	// Add mobile events that don't exist in category events
-	categoryQueryEventNames := make(map[string]bool)
-	for _, event := range categoryQueryEvents {
-		categoryQueryEventNames[event.Name] = true
-	}
-
-	for name, event := range mobileQueryEventMap {
-		if !categoryQueryEventNames[name] {
-			result = append(result, event)
-		}
-	}
-
-	return result
+	// Merge results, category events coming first they take precedence over the same mobile events
+	return application.DeduplicateEvents(append(categoryQueryEvents, mobileQueryEvents...)), errors.Join(errs...)
 }
 
 type allEventsCategoryQueryRequest struct {
@@ -175,17 +171,17 @@ type allEventsMobileQueryResponse struct {
 	Error         int                          `json:"error"`
 }
 
-func (c *allEventsCollector) categoryQueryCollect(location application.CollectLocation, category string) ([]application.Event, error) {
+func (c *allEventsCollector) categoryQueryCollect(ctx context.Context, location application.CollectLocation, category string) ([]application.Event, error) {
 	reqBody := allEventsCategoryQueryRequest{
 		City:          location.City,
 		Page:          0,
-		Rows:          1000,
+		Rows:          c.rows,
 		Radius:        100000,
 		ExcludeCities: []string{"online"},
 		Category:      category,
 		IsTimeFilter:  true,
 		StartDate:     strconv.FormatInt(time.Now().AddDate(0, 0, -7).Unix(), 10),
-		EndDate:       strconv.FormatInt(time.Now().AddDate(0, 0, 15).Unix(), 10),
+		EndDate:       strconv.FormatInt(time.Now().Add(c.window).Unix(), 10),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -193,7 +189,7 @@ func (c *allEventsCollector) categoryQueryCollect(location application.CollectLo
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://allevents.in/api/index.php/events/find-events-from-nearby-cities", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/index.php/events/find-events-from-nearby-cities", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -267,7 +263,10 @@ func categoryQueryToEvents(allEventsResp allEventsCategoryQueryResponse) ([]appl
 					price = &priceVal
 				}
 			}
-			priceCurrency = eventData.Tickets.TicketCurrency
+			// A currency without price would make the event look free
+			if price != nil {
+				priceCurrency = eventData.Tickets.TicketCurrency
+			}
 		}
 
 		var place string
@@ -297,7 +296,7 @@ func categoryQueryToEvents(allEventsResp allEventsCategoryQueryResponse) ([]appl
 	return events, nil
 }
 
-func (c *allEventsCollector) mobileQueryCollect(location application.CollectLocation) ([]application.Event, error) {
+func (c *allEventsCollector) mobileQueryCollect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
 	lat := strconv.FormatFloat(location.Lat, 'f', 10, 64)
 	lon := strconv.FormatFloat(location.Lon, 'f', 10, 64)
 
@@ -308,9 +307,9 @@ func (c *allEventsCollector) mobileQueryCollect(location application.CollectLoca
 		StartDate:       time.Now().Format("2006-01-02"),
 		SearchScope:     "city",
 		Page:            0,
-		Rows:            3000,
+		Rows:            c.rows,
 		ShowLongDateFmt: false,
-		Distance:        50,
+		Distance:        int(math.Round(c.radius)),
 		UserLat:         lat,
 		UserLong:        lon,
 	}
@@ -320,7 +319,7 @@ func (c *allEventsCollector) mobileQueryCollect(location application.CollectLoca
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://allevents.in/api/index.php/mobile_apps/v2/qs/search_with_filters_v2", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/index.php/mobile_apps/v2/qs/search_with_filters_v2", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -394,7 +393,10 @@ func mobileQueryToEvents(allEventsResp allEventsMobileQueryResponse) ([]applicat
 					price = &priceVal
 				}
 			}
-			priceCurrency = eventData.Tickets.TicketCurrency
+			// A currency without price would make the event look free
+			if price != nil {
+				priceCurrency = eventData.Tickets.TicketCurrency
+			}
 		}
 
 		var place string
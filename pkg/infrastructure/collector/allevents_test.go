@@ -0,0 +1,102 @@
+package collector_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+func TestAllEventsCollector(t *testing.T) {
+	events, err := replayedCollector(t, "allevents", "allevents", collector.Options{Categories: []string{"music"}}).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the event found by both APIs is kept once, the one of the category API first
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	electro := events[0]
+	if electro.Kind != application.KindParty || electro.Price == nil || *electro.Price != 15.5 || len(electro.Genres) != 2 {
+		t.Errorf("expected the kind, price and genres of the category API, got %+v", electro)
+	}
+
+	assertGolden(t, "allevents", events)
+}
+
+func TestAllEventsCollectorFailures(t *testing.T) {
+	tests := map[string]struct {
+		// failing is the path of the API failing, all of them if empty
+		failing         string
+		expectedMessage string
+	}{
+		"all queries":  {failing: "", expectedMessage: "all queries failed"},
+		"category API": {failing: "/api/index.php/events/find-events-from-nearby-cities", expectedMessage: "category music"},
+		"mobile API":   {failing: "/api/index.php/mobile_apps/v2/qs/search_with_filters_v2", expectedMessage: "mobile API"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.failing == "" || r.URL.Path == test.failing {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write([]byte(`{"search_result": [], "data": []}`))
+			}))
+			defer server.Close()
+
+			options := collector.Options{BaseURL: server.URL, RateLimit: 1000, Categories: []string{"music"}}
+			config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "allevents", Options: options}}}
+			c, err := config.Collector("allevents")
+			if err != nil {
+				t.Fatalf("failed to build collector: %v", err)
+			}
+
+			events, err := c.Collect(context.Background(), lyon)
+			if err == nil || !strings.Contains(err.Error(), test.expectedMessage) {
+				t.Fatalf("expected an error about %s, got %v", test.expectedMessage, err)
+			}
+			if test.failing != "" && events == nil {
+				t.Errorf("expected the events of the successful queries")
+			}
+		})
+	}
+}
+
+func TestAllEventsCollectorMobileQuery(t *testing.T) {
+	var mobileQuery struct {
+		Rows     int `json:"rows"`
+		Distance int `json:"distance"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/search_with_filters_v2") {
+			if err := json.NewDecoder(r.Body).Decode(&mobileQuery); err != nil {
+				t.Errorf("failed to decode mobile query: %v", err)
+			}
+		}
+		w.Write([]byte(`{"search_result": [], "data": []}`))
+	}))
+	defer server.Close()
+
+	options := collector.Options{BaseURL: server.URL, RateLimit: 1000, Categories: []string{"music"}, PageSize: 200, Radius: 1.5}
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "allevents", Options: options}}}
+	c, err := config.Collector("allevents")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	if _, err := c.Collect(context.Background(), lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the mobile API takes whole kilometers
+	if mobileQuery.Rows != 200 || mobileQuery.Distance != 2 {
+		t.Errorf("expected 200 rows within 2km, got %+v", mobileQuery)
+	}
+}
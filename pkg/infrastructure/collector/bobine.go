@@ -1,40 +1,42 @@
 package collector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/leorolland/sortir.in/pkg/application"
-	"golang.org/x/text/unicode/norm"
 )
 
+// bobineBaseURL is the default base URL of the bobine API
+const bobineBaseURL = "https://bobine.art"
+
 type bobineCollector struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
+	// radius is the distance searched around the location, in kilometers
+	radius   float64
+	pageSize int
+	window   time.Duration
 }
 
 func NewBobineCollector() application.Collector {
-	return &bobineCollector{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}
+	return newBobineCollector(Options{})
 }
 
-func removeAccents(s string) string {
-	t := norm.NFD.String(s)
-	result := make([]rune, 0, len(t))
-	for _, r := range t {
-		if unicode.IsMark(r) {
-			continue
-		}
-		result = append(result, r)
+func newBobineCollector(options Options) application.Collector {
+	return &bobineCollector{
+		client:   options.uncachedClient(),
+		baseURL:  or(options.BaseURL, bobineBaseURL),
+		radius:   or(options.Radius, 10),
+		pageSize: or(options.PageSize, 20),
+		window:   or(time.Duration(options.Window), 2*24*time.Hour),
 	}
-	return string(result)
 }
 
 type bobineMovie struct {
@@ -61,7 +63,7 @@ func (m *bobineMovie) GetTitle() string {
 
 func (m *bobineMovie) GetURL() string {
 	titleVF := strings.ToLower(strings.ReplaceAll(m.TitleVF, " ", "-"))
-	titleVF = removeAccents(titleVF)
+	titleVF = application.RemoveAccents(titleVF)
 	return fmt.Sprintf("https://bobine.art/film/%s-%d", m.TitleVO, m.ID)
 }
 
@@ -104,50 +106,22 @@ type bobineResponse struct {
 	Theaters []bobineTheater `json:"theaters"`
 }
 
-func (c *bobineCollector) Collect(location application.CollectLocation) ([]application.Event, error) {
+// Collect searches the showtimes of the window, the next 2 days by default. It is not incremental: showtimes change
+// within the whole window, which is searched again on every run.
+func (c *bobineCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	return c.collectWindow(ctx, location, now, now.Add(c.window))
+}
+
+// collectWindow collects the showtimes between start and end, page by page
+func (c *bobineCollector) collectWindow(ctx context.Context, location application.CollectLocation, start, end time.Time) ([]application.Event, error) {
 	page := 1
-	pageSize := 20
 	allEvents := []application.Event{}
 
 	for {
-		// Search for showtimes in the next 2 days
-		startDate := time.Now().UTC().Format("2006-01-02T15:04:05Z")
-		endDate := time.Now().AddDate(0, 0, 2).UTC().Format("2006-01-02T15:04:05Z")
-
-		// Build the URL with query parameters
-		baseURL := "https://bobine.art/api/showtimes/search"
-		params := url.Values{}
-		params.Add("range", "10")
-		params.Add("order_by", "next_showtime")
-		params.Add("order_dir", "asc")
-		params.Add("latitude", fmt.Sprintf("%.7f", location.Lat))
-		params.Add("longitude", fmt.Sprintf("%.7f", location.Lon))
-		params.Add("start", startDate)
-		params.Add("end", endDate)
-		params.Add("page", fmt.Sprintf("%d", page))
-		params.Add("page_size", fmt.Sprintf("%d", pageSize))
-
-		requestURL := baseURL + "?" + params.Encode()
-
-		// Create and execute the request
-		req, err := http.NewRequest("GET", requestURL, nil)
+		bobineResp, err := c.collectPage(ctx, location, start, end, page)
 		if err != nil {
-			return nil, fmt.Errorf("error creating request: %w", err)
-		}
-
-		resp, err := c.client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("error executing request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		}
-
-		var bobineResp []bobineResponse
-		if err := json.NewDecoder(resp.Body).Decode(&bobineResp); err != nil {
-			return nil, fmt.Errorf("error decoding response: %w", err)
+			return nil, err
 		}
 
 		// If the response is empty, we've reached the end of the pages
@@ -168,6 +142,49 @@ func (c *bobineCollector) Collect(location application.CollectLocation) ([]appli
 	return allEvents, nil
 }
 
+// collectPage requests a page of showtimes, closing its body before the next page is requested
+func (c *bobineCollector) collectPage(ctx context.Context, location application.CollectLocation, start, end time.Time, page int) ([]bobineResponse, error) {
+	startDate := start.Format("2006-01-02T15:04:05Z")
+	endDate := end.Format("2006-01-02T15:04:05Z")
+
+	// Build the URL with query parameters
+	params := url.Values{}
+	params.Add("range", strconv.FormatFloat(c.radius, 'f', -1, 64))
+	params.Add("order_by", "next_showtime")
+	params.Add("order_dir", "asc")
+	params.Add("latitude", fmt.Sprintf("%.7f", location.Lat))
+	params.Add("longitude", fmt.Sprintf("%.7f", location.Lon))
+	params.Add("start", startDate)
+	params.Add("end", endDate)
+	params.Add("page", fmt.Sprintf("%d", page))
+	params.Add("page_size", fmt.Sprintf("%d", c.pageSize))
+
+	requestURL := c.baseURL + "/api/showtimes/search?" + params.Encode()
+
+	// Create and execute the request
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var bobineResp []bobineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bobineResp); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return bobineResp, nil
+}
+
 func toBobineEvents(bobineResp []bobineResponse) ([]application.Event, error) {
 	events := []application.Event{}
 
@@ -0,0 +1,102 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+func TestBobineCollector(t *testing.T) {
+	events, err := replayedCollector(t, "bobine", "bobine", collector.Options{}).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// one event per showtime, over all the pages
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	if events[0].Kind != application.KindMovie || events[0].End.Sub(events[0].Begin).Minutes() != 14 {
+		t.Errorf("expected a 14 minutes movie, got %s of %v", events[0].Kind, events[0].End.Sub(events[0].Begin))
+	}
+	if events[2].PriceCurrency != nil {
+		t.Errorf("expected no currency without price, got %s", *events[2].PriceCurrency)
+	}
+
+	assertGolden(t, "bobine", events)
+}
+
+func TestBobineCollectorBaseURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/showtimes/search" || r.URL.Query().Get("range") != "5" {
+			t.Errorf("expected a search within 5 km, got %s", r.URL)
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "bobine", Options: collector.Options{BaseURL: server.URL, Radius: 5}}}}
+	bobine, err := config.Collector("bobine")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	if _, err := bobine.Collect(context.Background(), lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestBobineCollectorWindow(t *testing.T) {
+	windows := [][2]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		windows = append(windows, [2]string{r.URL.Query().Get("start"), r.URL.Query().Get("end")})
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "bobine", Options: collector.Options{BaseURL: server.URL, RateLimit: 1000, Window: collector.Duration(48 * time.Hour)}}}}
+	bobine, err := config.Collector("bobine")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	// a cursor left by a previous version does not shrink the window, and is not advanced
+	previousEnd := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second).Format(time.RFC3339)
+	cursors := application.NewSourceCursors(map[string]string{"bobine": previousEnd})
+	ctx := application.WithSourceCursors(context.Background(), cursors)
+	before := time.Now().UTC().Truncate(time.Second)
+	if _, err := bobine.Collect(ctx, lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(windows) != 1 {
+		t.Fatalf("expected 1 request, got %v", windows)
+	}
+	start, err := time.Parse(time.RFC3339, windows[0][0])
+	if err != nil {
+		t.Fatalf("expected an RFC 3339 start, got %q", windows[0][0])
+	}
+	end, err := time.Parse(time.RFC3339, windows[0][1])
+	if err != nil {
+		t.Fatalf("expected an RFC 3339 end, got %q", windows[0][1])
+	}
+	if start.Before(before) || start.Sub(before) > time.Minute {
+		t.Errorf("expected a search from now, got %s", start)
+	}
+	if end.Sub(start) != 48*time.Hour {
+		t.Errorf("expected a 48h window, got %s to %s", start, end)
+	}
+	if cursor := cursors.Get("bobine"); cursor != previousEnd {
+		t.Errorf("expected the cursor to be left unchanged, got %q", cursor)
+	}
+}
@@ -0,0 +1,269 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+)
+
+// HTTPCacheEnv is the environment variable of the directory caching the responses of collectors, no cache if empty
+const HTTPCacheEnv = "SORTIR_HTTP_CACHE"
+
+type cacheStatsKey struct{}
+
+type cacheCounters struct {
+	hits, revalidated, misses atomic.Int64
+}
+
+// WithCacheStats counts how the HTTP cache answers the requests collectors make with the returned context, see CacheStats
+func WithCacheStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheStatsKey{}, &cacheCounters{})
+}
+
+// CacheStats returns how the HTTP cache answered the requests made with ctx, zero if ctx does not come from WithCacheStats
+func CacheStats(ctx context.Context) application.CacheStats {
+	counters, ok := ctx.Value(cacheStatsKey{}).(*cacheCounters)
+	if !ok {
+		return application.CacheStats{}
+	}
+	return application.CacheStats{
+		Hits:        int(counters.hits.Load()),
+		Revalidated: int(counters.revalidated.Load()),
+		Misses:      int(counters.misses.Load()),
+	}
+}
+
+func countCache(ctx context.Context, count func(*cacheCounters)) {
+	if counters, ok := ctx.Value(cacheStatsKey{}).(*cacheCounters); ok {
+		count(counters)
+	}
+}
+
+const (
+	// cacheRetention is how long an entry neither used nor revalidated is kept, unless the ttl is longer.
+	// It spans a few runs of the daily schedules, whose entries are revalidated rather than downloaded again.
+	cacheRetention = 7 * 24 * time.Hour
+	// cachePruneInterval is how often a transport removes the stale entries of its directory
+	cachePruneInterval = time.Hour
+)
+
+// cacheEntry is a successful response stored on disk
+type cacheEntry struct {
+	URL      string      `json:"url"`
+	StoredAt time.Time   `json:"stored_at"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+func (e cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cachingTransport answers the requests of a collector from responses stored in dir: within ttl without request,
+// then by revalidating them with their ETag or Last-Modified.
+// POST requests are cached too, keyed by their body, as collectors only POST searches.
+type cachingTransport struct {
+	transport http.RoundTripper
+	dir       string
+	ttl       time.Duration
+	// prunedAt is the unix time the stale entries were last removed
+	prunedAt atomic.Int64
+}
+
+func newCachingTransport(transport http.RoundTripper, dir string, ttl time.Duration) *cachingTransport {
+	return &cachingTransport{transport: transport, dir: dir, ttl: ttl}
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	t.pruneStale()
+
+	path, ok := t.path(req)
+	if !ok {
+		return t.transport.RoundTrip(req)
+	}
+
+	entry, cached := t.load(path)
+	if cached && t.ttl > 0 && time.Since(entry.StoredAt) < t.ttl {
+		countCache(ctx, func(c *cacheCounters) { c.hits.Add(1) })
+		return entry.response(req), nil
+	}
+
+	if cached {
+		conditional := req.Clone(ctx)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			conditional.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+			conditional.Header.Set("If-Modified-Since", lastModified)
+		}
+		req = conditional
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		countCache(ctx, func(c *cacheCounters) { c.revalidated.Add(1) })
+
+		entry.StoredAt = time.Now()
+		t.store(path, entry)
+		return entry.response(req), nil
+	}
+
+	countCache(ctx, func(c *cacheCounters) { c.misses.Add(1) })
+	if resp.StatusCode != http.StatusOK || !t.storable(resp) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(path, cacheEntry{URL: req.URL.String(), StoredAt: time.Now(), Header: resp.Header, Body: body})
+	return resp, nil
+}
+
+// path returns the file caching the response of req, false if req cannot be cached
+func (t *cachingTransport) path(req *http.Request) (string, bool) {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.String() + "\n"))
+
+	switch {
+	case req.Method == http.MethodGet:
+	case req.Method == http.MethodPost && req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return "", false
+		}
+		defer body.Close()
+		if _, err := io.Copy(hash, body); err != nil {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	return filepath.Join(t.dir, hex.EncodeToString(hash.Sum(nil))+".json"), true
+}
+
+// storable tells if resp is worth storing, i.e. it is fresh for a while or can be revalidated
+func (t *cachingTransport) storable(resp *http.Response) bool {
+	if strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return false
+	}
+	return t.ttl > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// pruneStale removes the entries which were neither stored nor revalidated during the retention, at most once per
+// cachePruneInterval. Their modification time is the last time they were written, see store.
+func (t *cachingTransport) pruneStale() {
+	now := time.Now()
+	prunedAt := t.prunedAt.Load()
+	if now.Sub(time.Unix(prunedAt, 0)) < cachePruneInterval || !t.prunedAt.CompareAndSwap(prunedAt, now.Unix()) {
+		return
+	}
+
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to prune HTTP cache", "dir", t.dir, "error", err)
+		}
+		return
+	}
+
+	retention := max(t.ttl, cacheRetention)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < retention {
+			continue
+		}
+		if err := os.Remove(filepath.Join(t.dir, entry.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to prune HTTP cache", "path", entry.Name(), "error", err)
+		}
+	}
+}
+
+func (t *cachingTransport) load(path string) (cacheEntry, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("failed to read HTTP cache", "path", path, "error", err)
+		}
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		slog.Warn("ignoring corrupted HTTP cache", "path", path, "error", err)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes entry to path through a temporary file, so that concurrent collects never read a partial entry.
+// Failing to store only costs a download on the next run, so it is logged.
+func (t *cachingTransport) store(path string, entry cacheEntry) {
+	if err := writeCacheEntry(path, entry); err != nil {
+		slog.Warn("failed to write HTTP cache", "path", path, "error", err)
+	}
+}
+
+func writeCacheEntry(path string, entry cacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,252 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+const parisEmptyPage = `{"total_count": 0, "results": []}`
+
+// cachedCollector builds a paris collector requesting server, caching its responses in a temporary directory
+func cachedCollector(t *testing.T, server *httptest.Server, options collector.Options) application.Collector {
+	t.Helper()
+
+	options.BaseURL = server.URL
+	options.CacheDir = t.TempDir()
+	options.RateLimit = 1000
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: options}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	return c
+}
+
+// collectTwice collects paris twice, returning how the cache answered
+func collectTwice(t *testing.T, c application.Collector) application.CacheStats {
+	t.Helper()
+
+	ctx := collector.WithCacheStats(context.Background())
+	for range 2 {
+		if _, err := c.Collect(ctx, paris); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	return collector.CacheStats(ctx)
+}
+
+func TestCacheRevalidatesWithETag(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	stats := collectTwice(t, cachedCollector(t, server, collector.Options{}))
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if stats != (application.CacheStats{Revalidated: 1, Misses: 1}) {
+		t.Errorf("expected a miss then a revalidation, got %+v", stats)
+	}
+}
+
+func TestCacheRevalidatesWithLastModified(t *testing.T) {
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	stats := collectTwice(t, cachedCollector(t, server, collector.Options{}))
+
+	if stats != (application.CacheStats{Revalidated: 1, Misses: 1}) {
+		t.Errorf("expected a miss then a revalidation, got %+v", stats)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	stats := collectTwice(t, cachedCollector(t, server, collector.Options{CacheTTL: collector.Duration(time.Hour)}))
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+	if stats != (application.CacheStats{Hits: 1, Misses: 1}) {
+		t.Errorf("expected a miss then a hit, got %+v", stats)
+	}
+}
+
+func TestCacheSkipsUncacheableResponses(t *testing.T) {
+	tests := map[string]func(w http.ResponseWriter){
+		"without validators": func(w http.ResponseWriter) {},
+		"with no-store": func(w http.ResponseWriter) {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "no-store")
+		},
+	}
+
+	for name, headers := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") != "" {
+					t.Errorf("expected an unconditional request")
+				}
+				headers(w)
+				w.Write([]byte(parisEmptyPage))
+			}))
+			defer server.Close()
+
+			stats := collectTwice(t, cachedCollector(t, server, collector.Options{}))
+
+			if stats != (application.CacheStats{Misses: 2}) {
+				t.Errorf("expected 2 misses, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	t.Setenv(collector.HTTPCacheEnv, "")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: collector.Options{BaseURL: server.URL, RateLimit: 1000}}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	if stats := collectTwice(t, c); stats != (application.CacheStats{}) {
+		t.Errorf("expected no cache, got %+v", stats)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestCacheDirFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(collector.HTTPCacheEnv, dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: collector.Options{BaseURL: server.URL, RateLimit: 1000}}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	if _, err := c.Collect(context.Background(), paris); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read cache directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected 1 cached response, got %d", len(entries))
+	}
+}
+
+func TestCachePrunesStaleEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(parisEmptyPage))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "stale.json")
+	recent := filepath.Join(dir, "recent.json")
+	for _, path := range []string{stale, recent} {
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("failed to write cache entry: %v", err)
+		}
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("failed to age cache entry: %v", err)
+	}
+
+	options := collector.Options{BaseURL: server.URL, RateLimit: 1000, CacheDir: dir}
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: options}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	if _, err := c.Collect(context.Background(), paris); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected the stale entry to be pruned, got %v", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("expected the recent entry to be kept, got %v", err)
+	}
+}
+
+func TestCacheSkipsBobine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	options := collector.Options{BaseURL: server.URL, RateLimit: 1000, CacheDir: dir}
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "bobine", Options: options}}}
+	c, err := config.Collector("bobine")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	ctx := collector.WithCacheStats(context.Background())
+	if _, err := c.Collect(ctx, lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats := collector.CacheStats(ctx); stats != (application.CacheStats{}) {
+		t.Errorf("expected no cache, got %+v", stats)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no cached response, got %d", len(entries))
+	}
+}
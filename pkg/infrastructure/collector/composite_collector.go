@@ -1,29 +1,150 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
-	"reflect"
+	"sync"
+	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
 )
 
+// NamedCollector is a collector identified by its name in reports and cursors
+type NamedCollector interface {
+	application.Collector
+	Name() string
+}
+
+// Named names a collector which is not built from the configuration
+func Named(name string, collector application.Collector) NamedCollector {
+	return &configuredCollector{collector: collector, name: name}
+}
+
 type compositeCollector struct {
-	collectors []application.Collector
+	collectors []NamedCollector
+	// workers is the maximum amount of collectors running in parallel,
+	// collectors are run sequentially and stop on the first error when 0
+	workers int
 }
 
-func NewCompositeCollector(collectors ...application.Collector) application.Collector {
+// NewCompositeCollector runs collectors one after another and fails on the first error
+func NewCompositeCollector(collectors ...NamedCollector) application.ReportingCollector {
 	return &compositeCollector{collectors: collectors}
 }
 
-func (c *compositeCollector) Collect(location application.CollectLocation) ([]application.Event, error) {
+// NewConcurrentCompositeCollector runs up to workers collectors in parallel.
+// Events of successful collectors are kept when others fail, the collect only fails if all collectors fail without events.
+func NewConcurrentCompositeCollector(workers int, collectors ...NamedCollector) application.ReportingCollector {
+	return &compositeCollector{collectors: collectors, workers: max(workers, 1)}
+}
+
+func (c *compositeCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	events, _, err := c.CollectWithReport(ctx, location)
+	return events, err
+}
+
+// CollectWithReport merges the events collected by several collectors for the same event
+func (c *compositeCollector) CollectWithReport(ctx context.Context, location application.CollectLocation) ([]application.Event, application.CollectReport, error) {
+	collect := c.collectSequentially
+	if c.workers > 0 {
+		collect = c.collectConcurrently
+	}
+
+	events, report, err := collect(ctx, location)
+	if err != nil {
+		return nil, report, err
+	}
+	return application.DeduplicateEvents(events), report, nil
+}
+
+func (c *compositeCollector) collectSequentially(ctx context.Context, location application.CollectLocation) ([]application.Event, application.CollectReport, error) {
+	report := application.CollectReport{Location: location}
 	allEvents := []application.Event{}
 	for _, collector := range c.collectors {
-		collectorEvents, err := collector.Collect(location)
-		if err != nil {
-			return nil, err
+		if err := ctx.Err(); err != nil {
+			return nil, report, err
+		}
+		collectorEvents, collectorReport := runCollector(ctx, collector, location)
+		report.Collectors = append(report.Collectors, collectorReport)
+		if collectorReport.Err != nil {
+			return nil, report, collectorReport.Err
 		}
-		slog.Info("Collected events", "count", len(collectorEvents), "location", location.City, "collector", reflect.TypeOf(collector))
 		allEvents = append(allEvents, collectorEvents...)
 	}
-	return allEvents, nil
+	return allEvents, report, nil
+}
+
+func (c *compositeCollector) collectConcurrently(ctx context.Context, location application.CollectLocation) ([]application.Event, application.CollectReport, error) {
+	collectorsEvents := make([][]application.Event, len(c.collectors))
+	report := application.CollectReport{
+		Location:   location,
+		Collectors: make([]application.CollectorReport, len(c.collectors)),
+	}
+
+	semaphore := make(chan struct{}, c.workers)
+	var wg sync.WaitGroup
+	for i, collector := range c.collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				report.Collectors[i] = application.CollectorReport{Collector: collector.Name(), Err: ctx.Err()}
+				return
+			}
+
+			collectorsEvents[i], report.Collectors[i] = runCollector(ctx, collector, location)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, report, err
+	}
+
+	// Keep the collectors order so that the result is deterministic
+	allEvents := []application.Event{}
+	errs := []error{}
+	for i, collectorReport := range report.Collectors {
+		if collectorReport.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collectorReport.Collector, collectorReport.Err))
+		}
+		// A partially failed collector still returns the events it collected
+		allEvents = append(allEvents, collectorsEvents[i]...)
+	}
+
+	if len(c.collectors) > 0 && len(errs) == len(c.collectors) && len(allEvents) == 0 {
+		return nil, report, fmt.Errorf("all collectors failed: %w", errors.Join(errs...))
+	}
+
+	return allEvents, report, nil
+}
+
+func runCollector(ctx context.Context, collector NamedCollector, location application.CollectLocation) ([]application.Event, application.CollectorReport) {
+	report := application.CollectorReport{Collector: collector.Name()}
+	if configured, ok := collector.(*configuredCollector); ok {
+		report.Incremental = configured.incremental(ctx)
+	}
+
+	startedAt := time.Now()
+	events, err := collector.Collect(ctx, location)
+	report.Duration = time.Since(startedAt)
+	report.Err = err
+	if err != nil && len(events) == 0 {
+		return nil, report
+	}
+
+	report.Events = len(events)
+	for _, event := range events {
+		if !event.IsValid() {
+			report.Invalid++
+		}
+	}
+	slog.Info("Collected events", "count", len(events), "location", location.City, "collector", report.Collector, "duration", report.Duration)
+	return events, report
 }
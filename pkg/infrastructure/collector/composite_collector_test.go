@@ -0,0 +1,158 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+type fakeCollector struct {
+	events  []application.Event
+	err     error
+	running *atomic.Int32
+	maxSeen *atomic.Int32
+}
+
+func (c *fakeCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	if c.running != nil {
+		running := c.running.Add(1)
+		defer c.running.Add(-1)
+		for {
+			seen := c.maxSeen.Load()
+			if running <= seen || c.maxSeen.CompareAndSwap(seen, running) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return c.events, c.err
+}
+
+// incrementalFakeCollector collects nothing new since any cursor
+type incrementalFakeCollector struct{}
+
+func (incrementalFakeCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	return []application.Event{}, nil
+}
+
+func (incrementalFakeCollector) CollectSince(ctx context.Context, location application.CollectLocation, cursor string) ([]application.Event, string, error) {
+	return []application.Event{}, cursor, nil
+}
+
+var paris = application.CollectLocation{City: "Paris", Lat: 48.8566, Lon: 2.3522, Radius: 10}
+
+func TestCompositeCollectorSequentialStopsOnError(t *testing.T) {
+	composite := collector.NewCompositeCollector(
+		collector.Named("first", &fakeCollector{events: []application.Event{{Name: "Event 1"}}}),
+		collector.Named("failing", &fakeCollector{err: errors.New("upstream changed its API")}),
+		collector.Named("last", &fakeCollector{events: []application.Event{{Name: "Event 2"}}}),
+	)
+
+	_, report, err := composite.CollectWithReport(context.Background(), paris)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if len(report.Collectors) != 2 {
+		t.Fatalf("expected 2 collector reports, got %d", len(report.Collectors))
+	}
+}
+
+func TestCompositeCollectorConcurrentKeepsSuccessfulResults(t *testing.T) {
+	composite := collector.NewConcurrentCompositeCollector(2,
+		collector.Named("first", &fakeCollector{events: []application.Event{{Name: "Event 1"}}}),
+		collector.Named("failing", &fakeCollector{err: errors.New("upstream changed its API")}),
+		collector.Named("last", &fakeCollector{events: []application.Event{{Name: "Event 2"}, {Name: "Event 3"}}}),
+	)
+
+	events, report, err := composite.CollectWithReport(context.Background(), paris)
+	if err != nil {
+		t.Fatalf("failed to collect events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if len(report.Collectors) != 3 {
+		t.Fatalf("expected 3 collector reports, got %d", len(report.Collectors))
+	}
+	if report.Collectors[2].Events != 2 {
+		t.Errorf("expected 2 events reported for the third collector, got %d", report.Collectors[2].Events)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Collector != "failing" {
+		t.Errorf("expected the failing collector to be reported by its name, got %+v", failed)
+	}
+}
+
+func TestCompositeCollectorConcurrentKeepsPartialResults(t *testing.T) {
+	composite := collector.NewConcurrentCompositeCollector(2,
+		collector.Named("partial", &fakeCollector{events: []application.Event{{Name: "Event 1"}}, err: errors.New("a query failed")}),
+		collector.Named("failing", &fakeCollector{err: errors.New("upstream changed its API")}),
+	)
+
+	events, report, err := composite.CollectWithReport(context.Background(), paris)
+	if err != nil {
+		t.Fatalf("failed to collect events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected the event of the partially failed collector, got %d", len(events))
+	}
+	if failed := report.Failed(); len(failed) != 2 || failed[0].Events != 1 {
+		t.Errorf("expected both collectors reported as failed, the partial one with its event, got %+v", failed)
+	}
+}
+
+func TestCompositeCollectorReportsIncrementalCollects(t *testing.T) {
+	composite := collector.NewCompositeCollector(
+		collector.Named("incremental", incrementalFakeCollector{}),
+		collector.Named("new", incrementalFakeCollector{}),
+		collector.Named("full", &fakeCollector{}),
+	)
+
+	cursors := application.NewSourceCursors(map[string]string{"incremental": "a", "full": "b"})
+	_, report, err := composite.CollectWithReport(application.WithSourceCursors(context.Background(), cursors), paris)
+	if err != nil {
+		t.Fatalf("failed to collect events: %v", err)
+	}
+
+	// only a collector continuing from its cursor is incremental
+	for i, expected := range []bool{true, false, false} {
+		if report.Collectors[i].Incremental != expected {
+			t.Errorf("expected %s to be incremental: %v, got %v", report.Collectors[i].Collector, expected, report.Collectors[i].Incremental)
+		}
+	}
+}
+
+func TestCompositeCollectorConcurrentAllFailed(t *testing.T) {
+	composite := collector.NewConcurrentCompositeCollector(2,
+		collector.Named("first", &fakeCollector{err: errors.New("error 1")}),
+		collector.Named("second", &fakeCollector{err: errors.New("error 2")}),
+	)
+
+	_, err := composite.Collect(context.Background(), paris)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestCompositeCollectorConcurrentRespectsWorkerLimit(t *testing.T) {
+	running := &atomic.Int32{}
+	maxSeen := &atomic.Int32{}
+
+	collectors := []collector.NamedCollector{}
+	for i := range 6 {
+		collectors = append(collectors, collector.Named(fmt.Sprintf("collector-%d", i), &fakeCollector{running: running, maxSeen: maxSeen}))
+	}
+
+	composite := collector.NewConcurrentCompositeCollector(2, collectors...)
+	if _, err := composite.Collect(context.Background(), paris); err != nil {
+		t.Fatalf("failed to collect events: %v", err)
+	}
+	if maxSeen.Load() > 2 {
+		t.Fatalf("expected at most 2 collectors running in parallel, got %d", maxSeen.Load())
+	}
+}
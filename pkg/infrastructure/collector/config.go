@@ -0,0 +1,328 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTimeout is the timeout of each request of collectors
+const defaultTimeout = 10 * time.Second
+
+// Options are the parameters of a collector, zero values keeping the defaults of the collector
+type Options struct {
+	// Categories are the categories queried by allevents
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+	// Radius is the distance around the location searched by allevents and bobine, in kilometers
+	Radius float64 `json:"radius,omitempty" yaml:"radius,omitempty"`
+	// PageSize is the amount of events requested at once by allevents, bobine and paris, at most 100 for paris
+	PageSize int `json:"page_size,omitempty" yaml:"page_size,omitempty"`
+	// Window is how far ahead events are collected by allevents, bobine and ics
+	Window Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	// Timeout is the timeout of each attempt of a request, 10s by default
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// RateLimit is the amount of requests per second sent to the host of a request, 2 by default.
+	// Collectors of the same host share its rate limit, the lowest one applying.
+	RateLimit float64 `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// Retries is the amount of retries of a request on 429 and 5xx responses and network errors, 3 by default
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// RetryDelay is the delay before the first retry, doubled on each retry, 1s by default.
+	// A Retry-After header of the response replaces it.
+	RetryDelay Duration `json:"retry_delay,omitempty" yaml:"retry_delay,omitempty"`
+	// CacheDir is the directory caching the responses, instead of SORTIR_HTTP_CACHE
+	CacheDir string `json:"cache_dir,omitempty" yaml:"cache_dir,omitempty"`
+	// CacheTTL is how long cached responses are used without request, they are revalidated with their ETag
+	// or Last-Modified on each request if 0
+	CacheTTL Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"`
+	// File is the feeds file of ics or the seeds file of jsonld, instead of their environment variable
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	// BaseURL replaces the scheme and host of the API of allevents, bobine and paris, e.g. to go through a proxy
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	// Transport makes the requests of the collector, http.DefaultTransport if nil
+	Transport http.RoundTripper `json:"-" yaml:"-"`
+}
+
+// client returns the HTTP client of a collector, rate limiting and retrying its requests with the options,
+// and caching their responses when a cache directory is set.
+// The timeout applies to each attempt, so it is set on the transport rather than the client.
+func (o Options) client() *http.Client {
+	var transport http.RoundTripper = newPoliteTransport(o)
+	if dir := or(o.CacheDir, os.Getenv(HTTPCacheEnv)); dir != "" {
+		// Cache hits make no request, so they are neither rate limited nor counted in the budget
+		transport = newCachingTransport(transport, dir, time.Duration(o.CacheTTL))
+	}
+	return &http.Client{Transport: transport}
+}
+
+// uncachedClient is client without the HTTP cache, for collectors whose queries hold the current time and would never hit it
+func (o Options) uncachedClient() *http.Client {
+	return &http.Client{Transport: newPoliteTransport(o)}
+}
+
+// or returns value, or the default value of the option if value is zero
+func or[T comparable](value, defaultValue T) T {
+	var zero T
+	if value == zero {
+		return defaultValue
+	}
+	return value
+}
+
+func (o Options) Validate() error {
+	errs := []error{}
+
+	for _, category := range o.Categories {
+		if strings.TrimSpace(category) == "" {
+			errs = append(errs, errors.New("empty category"))
+		}
+	}
+	if o.Radius < 0 {
+		errs = append(errs, errors.New("radius must be positive"))
+	}
+	if o.PageSize < 0 {
+		errs = append(errs, errors.New("page size must be positive"))
+	}
+	if o.Window < 0 {
+		errs = append(errs, errors.New("window must be positive"))
+	}
+	if o.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must be positive"))
+	}
+	if o.RateLimit < 0 {
+		errs = append(errs, errors.New("rate limit must be positive"))
+	}
+	if o.Retries < 0 {
+		errs = append(errs, errors.New("retries must be positive"))
+	}
+	if o.RetryDelay < 0 {
+		errs = append(errs, errors.New("retry delay must be positive"))
+	}
+	if o.CacheTTL < 0 {
+		errs = append(errs, errors.New("cache ttl must be positive"))
+	}
+	if o.BaseURL != "" {
+		if u, err := url.Parse(o.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("invalid base url %q", o.BaseURL))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CollectorConfig is a collector of the registry, with its options and the cities it applies to
+type CollectorConfig struct {
+	// Name identifies the collector in schedules and reports
+	Name string `json:"name" yaml:"name"`
+	// Type is the registered collector, e.g. allevents, the name being used when empty.
+	// It allows several collectors of the same type with different options.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Enabled is true when omitted
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Cities restricts the collector to some cities, all enabled locations are collected when empty
+	Cities  []string `json:"cities,omitempty" yaml:"cities,omitempty"`
+	Options `yaml:",inline"`
+}
+
+func (c CollectorConfig) collectorType() string {
+	if c.Type == "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+func (c CollectorConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// Validate checks the collector, its cities being among locations
+func (c CollectorConfig) Validate(locations []application.CollectLocation) error {
+	errs := []error{}
+
+	if strings.TrimSpace(c.Name) == "" {
+		errs = append(errs, errors.New("missing name"))
+	}
+
+	if _, ok := constructors[c.collectorType()]; !ok {
+		errs = append(errs, fmt.Errorf("unknown collector type %q", c.collectorType()))
+	}
+
+	if err := validateCities(c.Cities, locations); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := c.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Config describes the collectors which can be run, and how
+type Config struct {
+	Collectors []CollectorConfig `json:"collectors" yaml:"collectors"`
+}
+
+// DefaultConfig enables every registered collector with its default options,
+// ics and jsonld only when their feeds or seeds file is set in the environment
+func DefaultConfig() Config {
+	disabled := false
+
+	config := Config{}
+	for _, name := range slices.Sorted(maps.Keys(constructors)) {
+		collectorConfig := CollectorConfig{Name: name}
+		if (name == "ics" && os.Getenv(ICSFeedsEnv) == "") || (name == "jsonld" && os.Getenv(JSONLDSeedsEnv) == "") {
+			collectorConfig.Enabled = &disabled
+		}
+		config.Collectors = append(config.Collectors, collectorConfig)
+	}
+	return config
+}
+
+// LoadConfig reads and validates the collectors configuration of a YAML (.yaml, .yml) or JSON file,
+// restricting collectors to cities among locations
+func LoadConfig(path string, locations []application.CollectLocation) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read collectors configuration file: %w", err)
+	}
+
+	var config Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &config)
+	default:
+		err = json.Unmarshal(content, &config)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse collectors configuration file: %w", err)
+	}
+
+	if err := config.Validate(locations); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func (c Config) Validate(locations []application.CollectLocation) error {
+	names := make(map[string]bool)
+	for i, collectorConfig := range c.Collectors {
+		if err := collectorConfig.Validate(locations); err != nil {
+			return fmt.Errorf("invalid collector %d: %w", i, err)
+		}
+		if names[collectorConfig.Name] {
+			return fmt.Errorf("invalid collector %d: duplicated name %q", i, collectorConfig.Name)
+		}
+		names[collectorConfig.Name] = true
+	}
+	return nil
+}
+
+// Enabled returns the names of the enabled collectors
+func (c Config) Enabled() []string {
+	names := []string{}
+	for _, collectorConfig := range c.Collectors {
+		if collectorConfig.IsEnabled() {
+			names = append(names, collectorConfig.Name)
+		}
+	}
+	return names
+}
+
+// Collector builds the enabled collector named name
+func (c Config) Collector(name string) (NamedCollector, error) {
+	index := slices.IndexFunc(c.Collectors, func(collectorConfig CollectorConfig) bool { return collectorConfig.Name == name })
+	if index < 0 {
+		return nil, fmt.Errorf("unknown collector %q", name)
+	}
+
+	collectorConfig := c.Collectors[index]
+	if !collectorConfig.IsEnabled() {
+		return nil, fmt.Errorf("collector %q is disabled", name)
+	}
+
+	constructor, ok := constructors[collectorConfig.collectorType()]
+	if !ok {
+		return nil, fmt.Errorf("unknown collector type %q", collectorConfig.collectorType())
+	}
+
+	return &configuredCollector{
+		collector: constructor(collectorConfig.Options),
+		name:      collectorConfig.Name,
+		cities:    collectorConfig.Cities,
+	}, nil
+}
+
+// EnabledCollectors builds all the enabled collectors
+func (c Config) EnabledCollectors() ([]NamedCollector, error) {
+	collectors := []NamedCollector{}
+	for _, name := range c.Enabled() {
+		collector, err := c.Collector(name)
+		if err != nil {
+			return nil, err
+		}
+		collectors = append(collectors, collector)
+	}
+	return collectors, nil
+}
+
+// validateCities checks that cities are the names of locations
+func validateCities(cities []string, locations []application.CollectLocation) error {
+	errs := []error{}
+	for _, city := range cities {
+		if !slices.ContainsFunc(locations, func(l application.CollectLocation) bool { return l.City == city }) {
+			errs = append(errs, fmt.Errorf("unknown or disabled city %q", city))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configuredCollector names a collector in reports and restricts it to some cities.
+// When the collect is incremental, the cursor of an incremental collector is the one of its name.
+type configuredCollector struct {
+	collector application.Collector
+	name      string
+	cities    []string
+}
+
+func (c *configuredCollector) Name() string {
+	return c.name
+}
+
+// incremental tells if the collect continues from the cursor of the collector, rather than collecting everything
+func (c *configuredCollector) incremental(ctx context.Context) bool {
+	_, ok := c.collector.(application.IncrementalCollector)
+	cursors := application.SourceCursorsFrom(ctx)
+	return ok && cursors != nil && cursors.Get(c.name) != ""
+}
+
+func (c *configuredCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	if len(c.cities) > 0 && !slices.Contains(c.cities, location.City) {
+		return []application.Event{}, nil
+	}
+
+	incremental, ok := c.collector.(application.IncrementalCollector)
+	cursors := application.SourceCursorsFrom(ctx)
+	if !ok || cursors == nil {
+		return c.collector.Collect(ctx, location)
+	}
+
+	events, cursor, err := incremental.CollectSince(ctx, location, cursors.Get(c.name))
+	if err != nil {
+		return nil, err
+	}
+	cursors.Set(c.name, cursor)
+	return events, nil
+}
@@ -0,0 +1,140 @@
+package collector_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write configuration file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := writeConfigFile(t, "collectors.yaml", `
+collectors:
+  - name: allevents-music
+    type: allevents
+    categories: [music, parties]
+    radius: 20
+    page_size: 500
+    window: 168h
+    timeout: 30s
+    cities: [Paris, Lyon]
+  - name: bobine
+    enabled: false
+  - name: paris
+`)
+
+	config, err := collector.LoadConfig(path, applicationtest.FrenchCities())
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	if len(config.Collectors) != 3 {
+		t.Fatalf("expected 3 collectors, got %d", len(config.Collectors))
+	}
+
+	music := config.Collectors[0]
+	if music.Type != "allevents" || len(music.Categories) != 2 || music.Radius != 20 || music.PageSize != 500 {
+		t.Errorf("expected the options of the collector, got %+v", music)
+	}
+	if time.Duration(music.Window) != 7*24*time.Hour || time.Duration(music.Timeout) != 30*time.Second {
+		t.Errorf("expected a 168h window and a 30s timeout, got %v and %v", time.Duration(music.Window), time.Duration(music.Timeout))
+	}
+
+	enabled := config.Enabled()
+	if len(enabled) != 2 || enabled[0] != "allevents-music" || enabled[1] != "paris" {
+		t.Errorf("expected the enabled collectors, got %v", enabled)
+	}
+
+	collectors, err := config.EnabledCollectors()
+	if err != nil || len(collectors) != 2 {
+		t.Errorf("expected 2 collectors, got %d, %v", len(collectors), err)
+	}
+
+	if _, err := config.Collector("bobine"); err == nil {
+		t.Errorf("expected an error for a disabled collector")
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := writeConfigFile(t, "collectors.json", `{"collectors": [{"name": "bobine", "radius": 5, "window": "24h"}]}`)
+
+	config, err := collector.LoadConfig(path, applicationtest.FrenchCities())
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+	if len(config.Collectors) != 1 || config.Collectors[0].Radius != 5 || time.Duration(config.Collectors[0].Window) != 24*time.Hour {
+		t.Errorf("expected the options of the collector, got %+v", config.Collectors)
+	}
+}
+
+func TestLoadConfigError(t *testing.T) {
+	testCases := map[string]string{
+		"when a type is unknown":      `{"collectors": [{"name": "foo"}]}`,
+		"when a name is missing":      `{"collectors": [{"type": "bobine"}]}`,
+		"when a name is duplicated":   `{"collectors": [{"name": "bobine"}, {"name": "bobine", "type": "bobine"}]}`,
+		"when a city is unknown":      `{"collectors": [{"name": "bobine", "cities": ["Atlantis"]}]}`,
+		"when the radius is negative": `{"collectors": [{"name": "bobine", "radius": -1}]}`,
+		"when the window is invalid":  `{"collectors": [{"name": "bobine", "window": "soon"}]}`,
+		"when retries are negative":   `{"collectors": [{"name": "bobine", "retries": -1}]}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := collector.LoadConfig(writeConfigFile(t, "collectors.json", content), applicationtest.FrenchCities()); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestConfiguredCollectorCities(t *testing.T) {
+	config, err := collector.LoadConfig(writeConfigFile(t, "collectors.json", `{"collectors": [{"name": "bobine-paris", "type": "bobine", "cities": ["Paris"]}]}`), applicationtest.FrenchCities())
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+
+	bobine, err := config.Collector("bobine-paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	composite := collector.NewCompositeCollector(bobine)
+
+	// Lyon is not collected, without any request
+	events, report, err := composite.CollectWithReport(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+	if len(report.Collectors) != 1 || report.Collectors[0].Collector != "bobine-paris" {
+		t.Errorf("expected the configured name in the report, got %+v", report.Collectors)
+	}
+}
+
+func TestLoadSchedulesWithConfig(t *testing.T) {
+	config, err := collector.LoadConfig(writeConfigFile(t, "collectors.yaml", "collectors:\n  - name: paris\n  - name: bobine\n    enabled: false\n"), applicationtest.FrenchCities())
+	if err != nil {
+		t.Fatalf("failed to load configuration: %v", err)
+	}
+
+	if _, err := collector.LoadSchedules(writeSchedulesFile(t, `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["paris"]}]}`), config, applicationtest.FrenchCities()); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := collector.LoadSchedules(writeSchedulesFile(t, `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["bobine"]}]}`), config, applicationtest.FrenchCities()); err == nil {
+		t.Errorf("expected an error for a disabled collector")
+	}
+}
@@ -0,0 +1,232 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultRateLimit is the amount of requests per second sent to a host by default
+	defaultRateLimit = 2.0
+	defaultRetries   = 3
+	// defaultRetryDelay is the delay before the first retry, doubled on each retry
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+	// maxRetryAfter is the longest Retry-After honored, the response is returned as is beyond
+	maxRetryAfter = time.Minute
+)
+
+// ErrRequestBudgetExceeded is returned by the requests of collectors once the budget of the run is spent
+var ErrRequestBudgetExceeded = errors.New("request budget exceeded")
+
+type requestBudgetKey struct{}
+
+// WithRequestBudget limits the amount of requests, retries included, that collectors make with the returned context
+func WithRequestBudget(ctx context.Context, requests int) context.Context {
+	budget := &atomic.Int64{}
+	budget.Store(int64(requests))
+	return context.WithValue(ctx, requestBudgetKey{}, budget)
+}
+
+// spendRequest takes a request from the budget of ctx, it returns false once the budget is spent
+func spendRequest(ctx context.Context) bool {
+	budget, ok := ctx.Value(requestBudgetKey{}).(*atomic.Int64)
+	if !ok {
+		return true
+	}
+	return budget.Add(-1) >= 0
+}
+
+// tokenBucket lets requests through at rate per second, holding a single token so that requests are evenly spaced
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, waiting until one is available
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(1, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	return sleep(ctx, delay)
+}
+
+var (
+	hostBucketsMu sync.Mutex
+	// hostBuckets are shared by all collectors, so that collectors of the same host share its rate limit
+	hostBuckets = make(map[string]*tokenBucket)
+)
+
+// hostBucket returns the bucket of host, its rate being the lowest one asked for
+func hostBucket(host string, rate float64) *tokenBucket {
+	hostBucketsMu.Lock()
+	defer hostBucketsMu.Unlock()
+
+	bucket, ok := hostBuckets[host]
+	if !ok {
+		bucket = &tokenBucket{rate: rate, tokens: 1, last: time.Now()}
+		hostBuckets[host] = bucket
+	}
+
+	bucket.mu.Lock()
+	bucket.rate = min(bucket.rate, rate)
+	bucket.mu.Unlock()
+	return bucket
+}
+
+// politeTransport sends the requests of a collector at the rate limit of their host, within the request budget of the run,
+// retrying them with exponential backoff and jitter on 429 and 5xx responses and network errors
+type politeTransport struct {
+	transport http.RoundTripper
+	// timeout is the timeout of each attempt
+	timeout    time.Duration
+	rateLimit  float64
+	retries    int
+	retryDelay time.Duration
+}
+
+func newPoliteTransport(options Options) *politeTransport {
+	transport := options.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &politeTransport{
+		transport:  transport,
+		timeout:    or(time.Duration(options.Timeout), defaultTimeout),
+		rateLimit:  or(options.RateLimit, defaultRateLimit),
+		retries:    or(options.Retries, defaultRetries),
+		retryDelay: or(time.Duration(options.RetryDelay), defaultRetryDelay),
+	}
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	bucket := hostBucket(req.URL.Host, t.rateLimit)
+
+	for attempt := 0; ; attempt++ {
+		if !spendRequest(ctx) {
+			return nil, ErrRequestBudgetExceeded
+		}
+		if err := bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.attempt(req, attempt)
+		retryable := attempt < t.retries && (req.Body == nil || req.GetBody != nil)
+		if err != nil {
+			if !retryable || ctx.Err() != nil {
+				return nil, err
+			}
+			if err := sleep(ctx, t.backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !retryable || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500) {
+			return resp, nil
+		}
+
+		delay, ok := retryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			delay = t.backoff(attempt)
+		} else if delay > maxRetryAfter {
+			return resp, nil
+		}
+
+		// The body is drained so that the connection is reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends the request once, with the timeout of an attempt
+func (t *politeTransport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	attemptReq := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("error rewinding request body: %w", err)
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.transport.RoundTrip(attemptReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout also covers reading the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff returns the delay before retrying after attempt, doubled on each attempt with jitter
+func (t *politeTransport) backoff(attempt int) time.Duration {
+	delay := min(t.retryDelay<<attempt, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,135 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+// bobinePage is a page of showtimes without events, so that bobine requests the next one
+const bobinePage = `[{"movie": {"id": 1}, "theaters": []}]`
+
+// politeCollector builds a bobine collector requesting server, which answers each request with the next of responses,
+// the last one being repeated
+func politeCollector(t *testing.T, options collector.Options, responses ...func(w http.ResponseWriter)) (application.Collector, *atomic.Int32) {
+	t.Helper()
+
+	requests := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(requests.Add(1)) - 1
+		responses[min(i, len(responses)-1)](w)
+	}))
+	t.Cleanup(server.Close)
+
+	options.BaseURL = server.URL
+	if options.RateLimit == 0 {
+		// requests are not spaced unless the test is about it
+		options.RateLimit = 1000
+	}
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "bobine", Options: options}}}
+	bobine, err := config.Collector("bobine")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	return bobine, requests
+}
+
+func status(code int, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func body(content string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Write([]byte(content))
+	}
+}
+
+func TestCollectorRetriesWithRetryAfter(t *testing.T) {
+	bobine, requests := politeCollector(t, collector.Options{},
+		status(http.StatusTooManyRequests, "Retry-After", "0"),
+		status(http.StatusServiceUnavailable, "Retry-After", time.Now().UTC().Format(http.TimeFormat)),
+		body("[]"),
+	)
+
+	if _, err := bobine.Collect(context.Background(), lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestCollectorRetriesWithBackoff(t *testing.T) {
+	bobine, requests := politeCollector(t, collector.Options{Retries: 2, RetryDelay: collector.Duration(time.Millisecond)},
+		status(http.StatusInternalServerError),
+	)
+
+	_, err := bobine.Collect(context.Background(), lyon)
+	if err == nil || err.Error() != "unexpected status code: 500" {
+		t.Errorf("expected the status of the last attempt, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestCollectorDoesNotRetry(t *testing.T) {
+	tests := map[string]func(w http.ResponseWriter){
+		"client error":      status(http.StatusNotFound),
+		"long retry after":  status(http.StatusServiceUnavailable, "Retry-After", "3600"),
+		"successful status": body("[]"),
+	}
+
+	for name, response := range tests {
+		t.Run(name, func(t *testing.T) {
+			bobine, requests := politeCollector(t, collector.Options{RetryDelay: collector.Duration(time.Millisecond)}, response)
+
+			bobine.Collect(context.Background(), lyon)
+			if requests.Load() != 1 {
+				t.Errorf("expected 1 request, got %d", requests.Load())
+			}
+		})
+	}
+}
+
+func TestCollectorRequestBudget(t *testing.T) {
+	bobine, requests := politeCollector(t, collector.Options{}, body(bobinePage))
+
+	ctx := collector.WithRequestBudget(context.Background(), 3)
+	_, err := bobine.Collect(ctx, lyon)
+	if !errors.Is(err, collector.ErrRequestBudgetExceeded) {
+		t.Errorf("expected the budget to be exceeded, got %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestCollectorRateLimit(t *testing.T) {
+	bobine, requests := politeCollector(t, collector.Options{RateLimit: 20}, body(bobinePage), body(bobinePage), body("[]"))
+
+	start := time.Now()
+	if _, err := bobine.Collect(context.Background(), lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the first request goes through at once, the next ones 50ms apart
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected 3 requests to take at least 100ms, took %v", elapsed)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+}
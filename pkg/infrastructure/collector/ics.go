@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/ical"
+)
+
+// ICSFeedsEnv is the path of the feeds file read by the "ics" collector
+const ICSFeedsEnv = "SORTIR_ICS_FEEDS"
+
+// defaultTimezone is the time zone of the dates published without time zone
+const defaultTimezone = "Europe/Paris"
+
+// ICSFeed is an iCalendar feed published by a venue or an association
+type ICSFeed struct {
+	URL string `json:"url"`
+	// Kind is the kind of the events whose categories match no kind
+	Kind application.Kind `json:"kind"`
+	// Lat and Lon locate the venue, for events without GEO property
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+	// Place names the venue, the LOCATION of events is used when empty
+	Place   string `json:"place,omitempty"`
+	Address string `json:"address,omitempty"`
+	// Timezone is the time zone of dates without one, Europe/Paris by default
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// LoadICSFeeds reads and validates the feeds of a JSON file, listed under "feeds"
+func LoadICSFeeds(path string) ([]ICSFeed, error) {
+	return loadPageSources[ICSFeed](path, "feeds", "ICS feed")
+}
+
+func (f ICSFeed) Validate() error {
+	errs := []error{}
+
+	if err := validatePageURL(f.URL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if f.Kind == "" {
+		errs = append(errs, errors.New("missing kind"))
+	} else if !f.Kind.IsKnown() {
+		errs = append(errs, fmt.Errorf("unknown kind %q", f.Kind))
+	}
+
+	if f.Lat < -90 || f.Lat > 90 || f.Lon < -180 || f.Lon > 180 || (f.Lat == 0 && f.Lon == 0) {
+		errs = append(errs, fmt.Errorf("invalid coordinates %f,%f", f.Lat, f.Lon))
+	}
+
+	if err := validateTimezone(f.Timezone); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f ICSFeed) pageURL() string {
+	return f.URL
+}
+
+// venue locates the events without GEO property, feeds are always located
+func (f ICSFeed) venue() *application.EventLocation {
+	return &application.EventLocation{Lat: f.Lat, Lon: f.Lon}
+}
+
+// timezoneLocation returns the time zone of dates published without one, Europe/Paris if timezone is empty
+func timezoneLocation(timezone string) *time.Location {
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+type icsCollector struct {
+	client *http.Client
+	feeds  []ICSFeed
+	// feedsPath is the file the feeds are read from on each collect, when feeds are not given
+	feedsPath string
+	// window is how far ahead recurring events are expanded
+	window time.Duration
+}
+
+// NewICSCollector collects the events of the feeds whose venue is within the collected location
+func NewICSCollector(feeds ...ICSFeed) application.Collector {
+	return &icsCollector{
+		client: Options{}.client(),
+		feeds:  feeds,
+		window: application.MaxEventDuration,
+	}
+}
+
+// NewICSCollectorFromEnv collects the feeds of the file set in SORTIR_ICS_FEEDS,
+// read on each collect so that feeds can be added without restarting the server
+func NewICSCollectorFromEnv() application.Collector {
+	return newICSCollector(Options{})
+}
+
+// newICSCollector collects the feeds of the file of the options, SORTIR_ICS_FEEDS by default
+func newICSCollector(options Options) application.Collector {
+	return &icsCollector{
+		client:    options.client(),
+		feedsPath: or(options.File, os.Getenv(ICSFeedsEnv)),
+		window:    or(time.Duration(options.Window), application.MaxEventDuration),
+	}
+}
+
+// Collect fetches the feeds near the location, expanding recurring events over the window, the next 15 days by default
+func (c *icsCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	feeds, err := c.getFeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPageSources(ctx, location, feeds, "ICS feed", c.collectFeed)
+}
+
+func (c *icsCollector) getFeeds() ([]ICSFeed, error) {
+	if c.feeds != nil {
+		return c.feeds, nil
+	}
+	if c.feedsPath == "" {
+		return nil, fmt.Errorf("no feeds file, %s is not set", ICSFeedsEnv)
+	}
+	return LoadICSFeeds(c.feedsPath)
+}
+
+func (c *icsCollector) collectFeed(ctx context.Context, feed ICSFeed) ([]application.Event, error) {
+	body, err := getPage(ctx, c.client, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	icsEvents, err := ical.Parse(body, timezoneLocation(feed.Timezone))
+	if err != nil {
+		if icsEvents == nil {
+			return nil, fmt.Errorf("error parsing calendar: %w", err)
+		}
+		slog.Warn("Skipped invalid events of ICS feed", "url", feed.URL, "error", err)
+	}
+
+	return toICSEvents(feed, icsEvents, time.Now(), c.window), nil
+}
+
+// toICSEvents returns the occurrences of the events within the window after now
+func toICSEvents(feed ICSFeed, icsEvents []ical.Event, now time.Time, window time.Duration) []application.Event {
+	events := []application.Event{}
+
+	for _, icsEvent := range icsEvents {
+		occurrences, err := icsEvent.Occurrences(now, now.Add(window))
+		if err != nil {
+			slog.Warn("Skipped ICS event with invalid recurrence", "url", feed.URL, "uid", icsEvent.UID, "error", err)
+			continue
+		}
+
+		for _, occurrence := range occurrences {
+			event := toICSEvent(feed, occurrence)
+			if !event.IsValid() {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+func toICSEvent(feed ICSFeed, icsEvent ical.Event) application.Event {
+	kind := application.FirstKindMatch(icsEvent.Categories)
+	if kind == application.KindUnknown {
+		kind = feed.Kind
+	}
+
+	loc := application.EventLocation{Lat: feed.Lat, Lon: feed.Lon}
+	if icsEvent.Geo != nil {
+		loc = application.EventLocation{Lat: icsEvent.Geo.Lat, Lon: icsEvent.Geo.Lon}
+	}
+
+	place := feed.Place
+	if place == "" {
+		// LOCATION is often the whole address, e.g. "Le Sonic, 4 quai des Étroits, Lyon"
+		place, _, _ = strings.Cut(icsEvent.Location, ",")
+		place = strings.TrimSpace(place)
+	}
+
+	source := icsEvent.URL
+	if source == "" {
+		source = feed.URL
+	}
+
+	return application.Event{
+		Name:    icsEvent.Summary,
+		Kind:    kind,
+		Genres:  icsEvent.Categories,
+		Begin:   icsEvent.Start,
+		End:     icsEvent.End,
+		Loc:     loc,
+		Place:   place,
+		Address: feed.Address,
+		Source:  source,
+	}
+}
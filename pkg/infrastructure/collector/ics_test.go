@@ -0,0 +1,172 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+var lyon = application.CollectLocation{City: "Lyon", Lat: 45.7640, Lon: 4.8357, Radius: 8}
+
+func serveFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", name))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/" + name
+}
+
+func TestICSCollectorSuccess(t *testing.T) {
+	feed := collector.ICSFeed{
+		URL:     serveFixture(t, "le-sonic.ics"),
+		Kind:    application.KindConcert,
+		Lat:     45.7500,
+		Lon:     4.8180,
+		Address: "4 quai des Étroits, 69005 Lyon",
+	}
+
+	events, err := collector.NewICSCollector(feed).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	byName := map[string][]application.Event{}
+	for _, event := range events {
+		if !event.IsValid() {
+			t.Errorf("expected valid events, got %+v", event)
+		}
+		if event.Begin.After(time.Now().Add(application.MaxEventDuration)) {
+			t.Errorf("expected events within 15 days, got %v", event.Begin)
+		}
+		byName[event.Name] = append(byName[event.Name], event)
+	}
+
+	jams := byName["Jam session"]
+	if len(jams) < 2 || len(jams) > 3 {
+		t.Fatalf("expected 2 or 3 weekly jam sessions, got %d", len(jams))
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	for _, jam := range jams {
+		if begin := jam.Begin.In(paris); begin.Weekday() != time.Tuesday || begin.Hour() != 20 || begin.Minute() != 30 {
+			t.Errorf("expected jam sessions on tuesdays at 20:30, got %v", begin)
+		}
+	}
+	if jams[0].Kind != application.KindConcert {
+		t.Errorf("expected the kind of the feed, got %s", jams[0].Kind)
+	}
+	if jams[0].Place != "Le Sonic" || jams[0].Address != feed.Address {
+		t.Errorf("expected the place from the location and the address of the feed, got %q, %q", jams[0].Place, jams[0].Address)
+	}
+	if jams[0].Loc != (application.EventLocation{Lat: feed.Lat, Lon: feed.Lon}) {
+		t.Errorf("expected the venue of the feed, got %+v", jams[0].Loc)
+	}
+	if jams[0].Source != "https://le-sonic.org/jam" {
+		t.Errorf("expected the url of the event as source, got %s", jams[0].Source)
+	}
+
+	expos := byName["Affiches de concerts"]
+	if len(expos) < 15 {
+		t.Fatalf("expected daily expos, got %d", len(expos))
+	}
+	if expos[0].Kind != application.KindExhibitions {
+		t.Errorf("expected the kind from the categories, got %s", expos[0].Kind)
+	}
+	if expos[0].Loc != (application.EventLocation{Lat: 45.7472, Lon: 4.8170}) {
+		t.Errorf("expected the location of the event, got %+v", expos[0].Loc)
+	}
+	if expos[0].Source != feed.URL {
+		t.Errorf("expected the feed as source, got %s", expos[0].Source)
+	}
+
+	if len(byName["Concert passé"]) != 0 || len(byName["Sans date"]) != 0 {
+		t.Errorf("expected past and invalid events to be skipped")
+	}
+}
+
+func TestICSCollectorSkipsFarFeeds(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	feed := collector.ICSFeed{URL: server.URL, Kind: application.KindConcert, Lat: 48.8566, Lon: 2.3522}
+
+	events, err := collector.NewICSCollector(feed).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 0 || requested {
+		t.Errorf("expected the feed not to be fetched, got %d events", len(events))
+	}
+}
+
+func TestICSCollectorFailsWhenAllFeedsFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// not a server error, which would be retried
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	broken := collector.ICSFeed{URL: server.URL, Kind: application.KindConcert, Lat: 45.7640, Lon: 4.8357}
+	working := collector.ICSFeed{URL: serveFixture(t, "le-sonic.ics"), Kind: application.KindConcert, Lat: 45.7500, Lon: 4.8180}
+
+	if _, err := collector.NewICSCollector(broken).Collect(context.Background(), lyon); err == nil {
+		t.Errorf("expected an error when the only feed fails")
+	}
+
+	events, err := collector.NewICSCollector(broken, working).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error when a feed succeeds, got %v", err)
+	}
+	if len(events) == 0 {
+		t.Errorf("expected the events of the working feed")
+	}
+}
+
+func TestLoadICSFeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feeds.json")
+	content := `{"feeds": [{"url": "https://le-sonic.org/agenda.ics", "kind": "concert", "lat": 45.75, "lon": 4.818}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write feeds file: %v", err)
+	}
+
+	feeds, err := collector.LoadICSFeeds(path)
+	if err != nil {
+		t.Fatalf("failed to load feeds: %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].Kind != application.KindConcert {
+		t.Errorf("expected 1 concert feed, got %+v", feeds)
+	}
+
+	testCases := map[string]string{
+		"when the url is invalid":      `{"feeds": [{"url": "le-sonic.org", "kind": "concert", "lat": 45.75, "lon": 4.818}]}`,
+		"when the kind is unknown":     `{"feeds": [{"url": "https://le-sonic.org", "kind": "opera", "lat": 45.75, "lon": 4.818}]}`,
+		"when the venue is missing":    `{"feeds": [{"url": "https://le-sonic.org", "kind": "concert"}]}`,
+		"when the timezone is unknown": `{"feeds": [{"url": "https://le-sonic.org", "kind": "concert", "lat": 45.75, "lon": 4.818, "timezone": "Lyon"}]}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "feeds.json")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write feeds file: %v", err)
+			}
+			if _, err := collector.LoadICSFeeds(path); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/jsonld"
+)
+
+// JSONLDSeedsEnv is the path of the seeds file read by the "jsonld" collector
+const JSONLDSeedsEnv = "SORTIR_JSONLD_SEEDS"
+
+// jsonLDKinds maps the schema.org event types to kinds, Event and unlisted types using the keywords or the seed kind
+var jsonLDKinds = map[string]application.Kind{
+	"MusicEvent":      application.KindConcert,
+	"TheaterEvent":    application.KindTheater,
+	"ComedyEvent":     application.KindTheater,
+	"ScreeningEvent":  application.KindMovie,
+	"Festival":        application.KindFestival,
+	"DanceEvent":      application.KindParty,
+	"SocialEvent":     application.KindParty,
+	"BusinessEvent":   application.KindBusiness,
+	"FoodEvent":       application.KindFoodDrinks,
+	"SportsEvent":     application.KindSports,
+	"ExhibitionEvent": application.KindExhibitions,
+	"VisualArtsEvent": application.KindExhibitions,
+	"EducationEvent":  application.KindWorkshop,
+	"ChildrensEvent":  application.KindWorkshop,
+	"LiteraryEvent":   application.KindWorkshop,
+	"SaleEvent":       application.KindFleaMarket,
+}
+
+// JSONLDSeed is a web page embedding schema.org events as JSON-LD, e.g. the agenda of a venue
+type JSONLDSeed struct {
+	URL string `json:"url"`
+	// Kind is the kind of the events whose type and keywords match no kind, unknown if empty
+	Kind application.Kind `json:"kind,omitempty"`
+	// Lat and Lon locate the venue, for events without geo. When set, the page is only fetched for the cities around it.
+	Lat float64 `json:"lat,omitempty"`
+	Lon float64 `json:"lon,omitempty"`
+	// Timezone is the time zone of dates without offset, Europe/Paris by default
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// LoadJSONLDSeeds reads and validates the seeds of a JSON file, listed under "seeds"
+func LoadJSONLDSeeds(path string) ([]JSONLDSeed, error) {
+	return loadPageSources[JSONLDSeed](path, "seeds", "JSON-LD seed")
+}
+
+func (s JSONLDSeed) Validate() error {
+	errs := []error{}
+
+	if err := validatePageURL(s.URL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.Kind != "" && !s.Kind.IsKnown() {
+		errs = append(errs, fmt.Errorf("unknown kind %q", s.Kind))
+	}
+
+	if s.Lat < -90 || s.Lat > 90 || s.Lon < -180 || s.Lon > 180 {
+		errs = append(errs, fmt.Errorf("invalid coordinates %f,%f", s.Lat, s.Lon))
+	}
+
+	if err := validateTimezone(s.Timezone); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s JSONLDSeed) pageURL() string {
+	return s.URL
+}
+
+// venue returns the location of the venue of the seed, nil if not set
+func (s JSONLDSeed) venue() *application.EventLocation {
+	if s.Lat == 0 && s.Lon == 0 {
+		return nil
+	}
+	return &application.EventLocation{Lat: s.Lat, Lon: s.Lon}
+}
+
+type jsonLDCollector struct {
+	client *http.Client
+	seeds  []JSONLDSeed
+	// seedsPath is read on each collect when seeds are not given
+	seedsPath string
+}
+
+// NewJSONLDCollector collects the schema.org events of the seeds located within the collected location
+func NewJSONLDCollector(seeds ...JSONLDSeed) application.Collector {
+	return &jsonLDCollector{
+		client: Options{}.client(),
+		seeds:  seeds,
+	}
+}
+
+// NewJSONLDCollectorFromEnv collects the seeds of the file set in SORTIR_JSONLD_SEEDS, reloaded like the ICS feeds file
+func NewJSONLDCollectorFromEnv() application.Collector {
+	return newJSONLDCollector(Options{})
+}
+
+// newJSONLDCollector collects the seeds of the file of the options, SORTIR_JSONLD_SEEDS by default
+func newJSONLDCollector(options Options) application.Collector {
+	return &jsonLDCollector{
+		client:    options.client(),
+		seedsPath: or(options.File, os.Getenv(JSONLDSeedsEnv)),
+	}
+}
+
+// Collect fetches the seeds near the location or without venue, and keeps the events located within the location
+func (c *jsonLDCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	seeds, err := c.getSeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := collectPageSources(ctx, location, seeds, "JSON-LD seed", c.collectSeed)
+	if err != nil {
+		return nil, err
+	}
+
+	locatedEvents := []application.Event{}
+	for _, event := range events {
+		if location.Contains(event.Loc) {
+			locatedEvents = append(locatedEvents, event)
+		}
+	}
+	return locatedEvents, nil
+}
+
+func (c *jsonLDCollector) getSeeds() ([]JSONLDSeed, error) {
+	if c.seeds != nil {
+		return c.seeds, nil
+	}
+	if c.seedsPath == "" {
+		return nil, fmt.Errorf("no seeds file, %s is not set", JSONLDSeedsEnv)
+	}
+	return LoadJSONLDSeeds(c.seedsPath)
+}
+
+func (c *jsonLDCollector) collectSeed(ctx context.Context, seed JSONLDSeed) ([]application.Event, error) {
+	body, err := getPage(ctx, c.client, seed.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	jsonLDEvents, err := jsonld.Extract(body, timezoneLocation(seed.Timezone))
+	if err != nil {
+		if jsonLDEvents == nil {
+			return nil, fmt.Errorf("error extracting events: %w", err)
+		}
+		slog.Warn("Skipped invalid events of JSON-LD seed", "url", seed.URL, "error", err)
+	}
+
+	return toJSONLDEvents(seed, jsonLDEvents), nil
+}
+
+func toJSONLDEvents(seed JSONLDSeed, jsonLDEvents []jsonld.Event) []application.Event {
+	events := []application.Event{}
+
+	for _, jsonLDEvent := range jsonLDEvents {
+		switch jsonLDEvent.Status {
+		case "EventCancelled", "EventPostponed", "EventMovedOnline":
+			continue
+		}
+		if jsonLDEvent.AttendanceMode == "OnlineEventAttendanceMode" {
+			continue
+		}
+
+		event, ok := toJSONLDEvent(seed, jsonLDEvent)
+		if !ok || !event.IsValid() {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// toJSONLDEvent maps a schema.org event, which cannot be located without geo nor venue
+func toJSONLDEvent(seed JSONLDSeed, jsonLDEvent jsonld.Event) (application.Event, bool) {
+	event := application.Event{
+		Name:   jsonLDEvent.Name,
+		Kind:   jsonLDKind(seed, jsonLDEvent),
+		Genres: jsonLDEvent.Keywords,
+		Begin:  jsonLDEvent.Start,
+		End:    jsonLDEvent.End,
+		Source: resolveURL(seed.URL, jsonLDEvent.URL),
+		Img:    resolveURL(seed.URL, jsonLDEvent.Image),
+	}
+	if event.Source == "" {
+		event.Source = seed.URL
+	}
+
+	if place := jsonLDEvent.Location; place != nil {
+		event.Place = place.Name
+		event.Address = place.Address
+		if place.Geo != nil {
+			event.Loc = application.EventLocation{Lat: place.Geo.Lat, Lon: place.Geo.Lon}
+		}
+	}
+	if event.Loc == (application.EventLocation{}) {
+		venue := seed.venue()
+		if venue == nil {
+			return application.Event{}, false
+		}
+		event.Loc = *venue
+	}
+
+	for _, offer := range jsonLDEvent.Offers {
+		if offer.Price == nil {
+			continue
+		}
+		price := *offer.Price
+		event.Price = &price
+		if offer.Currency != "" {
+			currency := offer.Currency
+			event.PriceCurrency = &currency
+		}
+		break
+	}
+
+	return event, true
+}
+
+func jsonLDKind(seed JSONLDSeed, jsonLDEvent jsonld.Event) application.Kind {
+	for _, t := range jsonLDEvent.Types {
+		if kind, ok := jsonLDKinds[t]; ok {
+			return kind
+		}
+	}
+	if kind := application.FirstKindMatch(jsonLDEvent.Keywords); kind != application.KindUnknown {
+		return kind
+	}
+	if seed.Kind != "" {
+		return seed.Kind
+	}
+	return application.KindUnknown
+}
+
+// resolveURL resolves a URL relative to the page it was found in, empty if invalid
+func resolveURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
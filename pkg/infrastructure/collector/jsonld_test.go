@@ -0,0 +1,141 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+func TestJSONLDCollectorSuccess(t *testing.T) {
+	seed := collector.JSONLDSeed{
+		URL:  serveFixture(t, "le-sonic.html"),
+		Kind: application.KindConcert,
+		Lat:  45.7500,
+		Lon:  4.8180,
+	}
+
+	events, err := collector.NewJSONLDCollector(seed).Collect(context.Background(), lyon)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, the cancelled, online, far and invalid ones being skipped, got %+v", events)
+	}
+
+	concert := events[0]
+	if concert.Name != "Sleaford Mods" || concert.Kind != application.KindConcert {
+		t.Errorf("expected a concert, got %q of kind %s", concert.Name, concert.Kind)
+	}
+	if !concert.Begin.Equal(time.Date(2099, 3, 14, 19, 30, 0, 0, time.UTC)) || !concert.End.Equal(time.Date(2099, 3, 14, 22, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected the dates of the event, got %v to %v", concert.Begin, concert.End)
+	}
+	if concert.Loc != (application.EventLocation{Lat: 45.7472, Lon: 4.8170}) {
+		t.Errorf("expected the geo of the location, got %+v", concert.Loc)
+	}
+	if concert.Place != "Le Sonic" || concert.Address != "4 quai des Étroits, 69005 Lyon" {
+		t.Errorf("expected the place and its postal address, got %q, %q", concert.Place, concert.Address)
+	}
+	if concert.Price == nil || *concert.Price != 12.5 || concert.PriceCurrency == nil || *concert.PriceCurrency != "EUR" {
+		t.Errorf("expected a 12.50 EUR price, got %v %v", concert.Price, concert.PriceCurrency)
+	}
+	if !strings.HasSuffix(concert.Source, "/agenda/sleaford-mods") || !strings.HasPrefix(concert.Source, "http://") {
+		t.Errorf("expected the url of the event resolved against the seed, got %s", concert.Source)
+	}
+	if !strings.HasSuffix(concert.Img, "/images/sleaford-mods.jpg") {
+		t.Errorf("expected the url of the image, got %s", concert.Img)
+	}
+	if len(concert.Genres) != 2 || concert.Genres[0] != "punk" {
+		t.Errorf("expected the keywords as genres, got %q", concert.Genres)
+	}
+
+	expo := events[1]
+	if expo.Kind != application.KindExhibitions {
+		t.Errorf("expected the kind from the keywords, got %s", expo.Kind)
+	}
+	if expo.Loc != (application.EventLocation{Lat: seed.Lat, Lon: seed.Lon}) {
+		t.Errorf("expected the venue of the seed, got %+v", expo.Loc)
+	}
+	if expo.End.Sub(expo.Begin) != 5*24*time.Hour {
+		t.Errorf("expected the expo to last until the end of its last day, got %v", expo.End.Sub(expo.Begin))
+	}
+	if expo.Price == nil || *expo.Price != 0 {
+		t.Errorf("expected a free expo, got %v", expo.Price)
+	}
+	if expo.Source != seed.URL {
+		t.Errorf("expected the seed as source, got %s", expo.Source)
+	}
+}
+
+func TestJSONLDCollectorWithoutVenue(t *testing.T) {
+	seed := collector.JSONLDSeed{URL: serveFixture(t, "le-sonic.html")}
+
+	events, err := collector.NewJSONLDCollector(seed).Collect(context.Background(), application.CollectLocation{City: "Paris", Lat: 48.8566, Lon: 2.3522, Radius: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// events without geo cannot be located without the venue of the seed
+	if len(events) != 1 || events[0].Name != "Concert à Paris" {
+		t.Fatalf("expected the event located in Paris only, got %+v", events)
+	}
+}
+
+func TestJSONLDCollectorSkipsFarSeeds(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	seed := collector.JSONLDSeed{URL: server.URL, Lat: 48.8566, Lon: 2.3522}
+
+	if _, err := collector.NewJSONLDCollector(seed).Collect(context.Background(), lyon); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if requested {
+		t.Errorf("expected the seed not to be fetched")
+	}
+}
+
+func TestLoadJSONLDSeeds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seeds.json")
+	content := `{"seeds": [{"url": "https://le-sonic.org/agenda", "kind": "concert", "lat": 45.75, "lon": 4.818}, {"url": "https://agenda.example.com"}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write seeds file: %v", err)
+	}
+
+	seeds, err := collector.LoadJSONLDSeeds(path)
+	if err != nil {
+		t.Fatalf("failed to load seeds: %v", err)
+	}
+	if len(seeds) != 2 {
+		t.Errorf("expected 2 seeds, got %+v", seeds)
+	}
+
+	testCases := map[string]string{
+		"when the url is invalid":  `{"seeds": [{"url": "/agenda"}]}`,
+		"when the kind is unknown": `{"seeds": [{"url": "https://le-sonic.org", "kind": "opera"}]}`,
+		"when the venue is wrong":  `{"seeds": [{"url": "https://le-sonic.org", "lat": 145.75, "lon": 4.818}]}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "seeds.json")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("failed to write seeds file: %v", err)
+			}
+			if _, err := collector.LoadJSONLDSeeds(path); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package collector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -14,28 +16,42 @@ import (
 	"github.com/leorolland/sortir.in/pkg/application"
 )
 
+const (
+	// parisEventsBaseURL is the default base URL of the Paris open data API
+	parisEventsBaseURL = "https://opendata.paris.fr"
+	// parisEventsDataset is the que-faire-a-paris- dataset in the Opendatasoft Explore v2.1 API
+	parisEventsDataset = "/api/explore/v2.1/catalog/datasets/que-faire-a-paris-"
+	// parisEventsMaxLimit is the maximum amount of records of a page
+	parisEventsMaxLimit = 100
+	// parisEventsMaxOffset is the maximum offset plus limit of the records endpoint, the export endpoint has no maximum
+	parisEventsMaxOffset = 10000
+)
+
 type parisEventsCollector struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
+	limit   int
 }
 
 func NewParisEventsCollector() application.Collector {
+	return newParisEventsCollector(Options{})
+}
+
+func newParisEventsCollector(options Options) application.Collector {
 	return &parisEventsCollector{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:  options.client(),
+		baseURL: or(options.BaseURL, parisEventsBaseURL),
+		limit:   min(or(options.PageSize, parisEventsMaxLimit), parisEventsMaxLimit),
 	}
 }
 
-// ParisEventsResponse represents the top-level response from the Paris Events API
+// parisEventsResponse is a page of the records endpoint
 type parisEventsResponse struct {
-	Records []struct {
-		RecordID string          `json:"recordid"`
-		Fields   json.RawMessage `json:"fields"`
-	} `json:"records"`
-	NHits int `json:"nhits"`
+	TotalCount int               `json:"total_count"`
+	Results    []json.RawMessage `json:"results"`
 }
 
-// ParisEventsFields contains the actual event data
+// parisEventsFields are the fields of a record
 type parisEventsFields struct {
 	ID             string  `json:"id"`
 	EventID        int     `json:"event_id"`
@@ -56,67 +72,140 @@ type parisEventsFields struct {
 	PriceType      string  `json:"price_type"`
 	PriceDetail    *string `json:"price_detail"`
 	QfapTags       string  `json:"qfap_tags"`
+	UpdatedAt      string  `json:"updated_at"`
 	// We'll handle lat_lon separately since it can be in different formats
 }
 
-func (c *parisEventsCollector) Collect(location application.CollectLocation) ([]application.Event, error) {
+func (c *parisEventsCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
+	events, _, err := c.CollectSince(ctx, location, "")
+	return events, err
+}
+
+// CollectSince collects the events which are not ended and were updated since the cursor,
+// the latest update date of the previously collected events
+func (c *parisEventsCollector) CollectSince(ctx context.Context, location application.CollectLocation, cursor string) ([]application.Event, string, error) {
 	// Only collect events for Paris
 	if location.City != "Paris" {
-		return []application.Event{}, nil
+		return []application.Event{}, cursor, nil
 	}
 
-	// Cannot add >= 2 filters because in this case the API limits the number of results to 100
-	// So better get the first 1000 ordered by date_start
-
-	// Build the URL with query parameters
-	baseURL := "https://opendata.paris.fr/api/records/1.0/search/"
+	where := "date_end >= now()"
+	if cursor != "" {
+		// Events updated at the cursor are collected again, in case others were updated at the same time
+		where += fmt.Sprintf(" AND updated_at >= date'%s'", cursor)
+	}
 	params := url.Values{}
-	params.Add("dataset", "que-faire-a-paris-")
-	params.Add("rows", "1000")
-	params.Add("sort", "date_start")
+	params.Add("where", where)
+	params.Add("order_by", "date_start,event_id")
+
+	records := []json.RawMessage{}
+	for offset := 0; ; offset += c.limit {
+		page, err := c.collectPage(ctx, params, offset)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if offset == 0 && page.TotalCount > parisEventsMaxOffset {
+			// The records beyond the maximum offset are only reachable through the export endpoint
+			slog.Info("Exporting Paris events", "count", page.TotalCount)
+			records, err = c.collectExport(ctx, params)
+			if err != nil {
+				return nil, "", err
+			}
+			break
+		}
 
-	requestURL := baseURL + "?" + params.Encode()
+		records = append(records, page.Results...)
+		if len(page.Results) < c.limit || offset+c.limit >= page.TotalCount {
+			break
+		}
+	}
 
+	slog.Info("Received Paris events", "count", len(records))
+
+	// Convert the records to events
+	events, err := toParisEvents(records)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return events, latestParisUpdate(records, cursor), nil
+}
+
+// collectPage requests the page of records starting at offset
+func (c *parisEventsCollector) collectPage(ctx context.Context, params url.Values, offset int) (parisEventsResponse, error) {
+	pageParams := maps.Clone(params)
+	pageParams.Set("limit", strconv.Itoa(c.limit))
+	pageParams.Set("offset", strconv.Itoa(offset))
+
+	var page parisEventsResponse
+	err := c.get(ctx, c.baseURL+parisEventsDataset+"/records?"+pageParams.Encode(), &page)
+	return page, err
+}
+
+// collectExport requests all the records at once
+func (c *parisEventsCollector) collectExport(ctx context.Context, params url.Values) ([]json.RawMessage, error) {
+	var records []json.RawMessage
+	err := c.get(ctx, c.baseURL+parisEventsDataset+"/exports/json?"+params.Encode(), &records)
+	return records, err
+}
+
+// get requests requestURL, decoding its JSON response into target
+func (c *parisEventsCollector) get(ctx context.Context, requestURL string, target any) error {
 	slog.Info("Requesting Paris events", "url", requestURL)
 
 	// Create and execute the request
-	req, err := http.NewRequest("GET", requestURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return fmt.Errorf("error creating request: %w", err)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %w", err)
+		return fmt.Errorf("error executing request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var parisResp parisEventsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parisResp); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
 	}
+	return nil
+}
 
-	slog.Info("Received Paris events", "count", parisResp.NHits)
+// latestParisUpdate returns the latest update date of the records, cursor if none of them is more recent
+func latestParisUpdate(records []json.RawMessage, cursor string) string {
+	latest, _ := time.Parse(time.RFC3339, cursor)
 
-	// Convert the response to events
-	events, err := toParisEvents(parisResp)
-	if err != nil {
-		return nil, err
+	for _, record := range records {
+		var fields parisEventsFields
+		if err := json.Unmarshal(record, &fields); err != nil {
+			continue
+		}
+		updatedAt, err := time.Parse(time.RFC3339, fields.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		if updatedAt.After(latest) {
+			latest = updatedAt
+		}
 	}
 
-	return events, nil
+	if latest.IsZero() {
+		return cursor
+	}
+	return latest.UTC().Format(time.RFC3339)
 }
 
-func toParisEvents(parisResp parisEventsResponse) ([]application.Event, error) {
+func toParisEvents(records []json.RawMessage) ([]application.Event, error) {
 	events := []application.Event{}
 
-	for _, record := range parisResp.Records {
+	for _, record := range records {
 		var eventFields parisEventsFields
-		if err := json.Unmarshal(record.Fields, &eventFields); err != nil {
+		if err := json.Unmarshal(record, &eventFields); err != nil {
 			slog.Warn("error unmarshaling event fields", "error", err)
 			continue
 		}
@@ -129,7 +218,7 @@ func toParisEvents(parisResp parisEventsResponse) ([]application.Event, error) {
 		// Extract lat/lon from raw fields
 		var lat, lon float64
 		var latLonMap map[string]interface{}
-		if err := json.Unmarshal(record.Fields, &latLonMap); err == nil {
+		if err := json.Unmarshal(record, &latLonMap); err == nil {
 			if latLonVal, ok := latLonMap["lat_lon"]; ok {
 				switch v := latLonVal.(type) {
 				case string:
@@ -250,7 +339,13 @@ func createEvent(eventFields parisEventsFields, startTime, endTime time.Time, la
 	var price *float64
 	var priceCurrency *string
 
-	if eventFields.PriceType != "gratuit" && eventFields.PriceDetail != nil {
+	if eventFields.PriceType == "gratuit" {
+		// Free events have a known price of 0
+		free := 0.0
+		currency := "EUR"
+		price = &free
+		priceCurrency = &currency
+	} else if eventFields.PriceDetail != nil {
 		// Extract the first number from the price detail
 		priceStr := *eventFields.PriceDetail
 
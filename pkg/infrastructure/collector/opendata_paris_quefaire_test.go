@@ -0,0 +1,143 @@
+package collector_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+// queryRecorder records the query of the requests made through transport
+type queryRecorder struct {
+	transport http.RoundTripper
+	queries   []url.Values
+}
+
+func (r *queryRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.queries = append(r.queries, req.URL.Query())
+	return r.transport.RoundTrip(req)
+}
+
+func TestParisEventsCollector(t *testing.T) {
+	paris := application.CollectLocation{City: "Paris", Lat: 48.8566, Lon: 2.3522, Radius: 10}
+
+	// the 4 records are requested in 2 pages
+	recorder := &queryRecorder{transport: newCassette(t, "paris")}
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: collector.Options{PageSize: 2, Transport: recorder}}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	events, err := c.Collect(context.Background(), paris)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// pages are sorted on a unique key, so that records never move between pages
+	if len(recorder.queries) != 2 {
+		t.Fatalf("expected 2 pages, got %d", len(recorder.queries))
+	}
+	for i, query := range recorder.queries {
+		expected := url.Values{
+			"limit":    {"2"},
+			"offset":   {strconv.Itoa(2 * i)},
+			"where":    {"date_end >= now()"},
+			"order_by": {"date_start,event_id"},
+		}
+		if query.Encode() != expected.Encode() {
+			t.Errorf("expected page %d to be requested with %s, got %s", i, expected.Encode(), query.Encode())
+		}
+	}
+
+	// one event per occurrence, the ended and undated events being skipped
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	organ := events[2]
+	if organ.Price == nil || *organ.Price != 25.5 || organ.Loc != (application.EventLocation{Lat: 48.8634, Lon: 2.3451}) {
+		t.Errorf("expected the first price and the coordinates of the event, got %+v", organ)
+	}
+
+	assertGolden(t, "paris", events)
+}
+
+func TestParisEventsCollectorOtherCity(t *testing.T) {
+	// the cassette is empty, no request is made
+	events, err := replayedCollector(t, "paris", "paris-other-city", collector.Options{}).Collect(context.Background(), lyon)
+	if err != nil || len(events) != 0 {
+		t.Errorf("expected no events, got %d, %v", len(events), err)
+	}
+}
+
+func TestParisEventsCollectorIncremental(t *testing.T) {
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("where"))
+		w.Write([]byte(`{"total_count": 2, "results": [
+			{"title": "Concert", "date_start": "2099-03-14T20:00:00+01:00", "date_end": "2099-03-14T22:00:00+01:00", "updated_at": "2025-11-20T10:00:00+01:00"},
+			{"title": "Expo", "date_start": "2099-03-14T10:00:00+01:00", "date_end": "2099-03-21T18:00:00+01:00", "updated_at": "2025-11-21T08:30:00+01:00"}
+		]}`))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: collector.Options{BaseURL: server.URL, RateLimit: 1000}}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	cursors := application.NewSourceCursors(nil)
+	ctx := application.WithSourceCursors(context.Background(), cursors)
+	for range 2 {
+		events, err := c.Collect(ctx, paris)
+		if err != nil || len(events) != 2 {
+			t.Fatalf("expected 2 events, got %d, %v", len(events), err)
+		}
+	}
+
+	// the first collect is full, the next one only asks for the events updated since the latest update
+	if len(queries) != 2 || queries[0] != "date_end >= now()" || queries[1] != "date_end >= now() AND updated_at >= date'2025-11-21T07:30:00Z'" {
+		t.Errorf("expected a full then an incremental query, got %q", queries)
+	}
+	if cursor := cursors.Get("paris"); cursor != "2025-11-21T07:30:00Z" {
+		t.Errorf("expected the latest update as cursor, got %q", cursor)
+	}
+}
+
+func TestParisEventsCollectorExport(t *testing.T) {
+	event := `{"title": "Concert", "date_start": "2099-03-14T20:00:00+01:00", "date_end": "2099-03-14T22:00:00+01:00"}`
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/exports/json") {
+			w.Write([]byte("[" + strings.Repeat(event+",", 2) + event + "]"))
+			return
+		}
+		// more records than the records endpoint can page through
+		w.Write([]byte(`{"total_count": 12000, "results": [` + event + `]}`))
+	}))
+	defer server.Close()
+
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: "paris", Options: collector.Options{BaseURL: server.URL, RateLimit: 1000}}}}
+	c, err := config.Collector("paris")
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+
+	events, err := c.Collect(context.Background(), paris)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("expected the 3 exported events, got %d", len(events))
+	}
+	if len(paths) != 2 || !strings.HasSuffix(paths[0], "/records") || !strings.HasSuffix(paths[1], "/exports/json") {
+		t.Errorf("expected a page then the export, got %v", paths)
+	}
+}
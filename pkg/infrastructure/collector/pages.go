@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+)
+
+// pageSource is a web page publishing events, listed in a file by the collectors which cannot discover them,
+// e.g. the iCalendar feed or the agenda of a venue
+type pageSource interface {
+	Validate() error
+	pageURL() string
+	// venue locates the events of the page, nil if the page locates them itself
+	venue() *application.EventLocation
+}
+
+// loadPageSources reads and validates the sources listed under key in a JSON file.
+// name designates a source in errors, e.g. "ICS feed".
+func loadPageSources[S pageSource](path, key, name string) ([]S, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %ss file: %w", name, err)
+	}
+
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %ss file: %w", name, err)
+	}
+
+	var sources []S
+	if list, ok := file[key]; ok {
+		if err := json.Unmarshal(list, &sources); err != nil {
+			return nil, fmt.Errorf("failed to parse %ss file: %w", name, err)
+		}
+	}
+
+	for i, source := range sources {
+		if err := source.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s %d: %w", name, i, err)
+		}
+	}
+
+	return sources, nil
+}
+
+// validatePageURL checks that rawURL is an absolute http(s) URL
+func validatePageURL(rawURL string) error {
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q", rawURL)
+	}
+	return nil
+}
+
+// validateTimezone checks that timezone is empty or a known time zone
+func validateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", timezone)
+	}
+	return nil
+}
+
+// collectPageSources collects, one after another, the sources whose venue is within the location or which have none.
+// Failing sources are logged and skipped, the collect only fails if all the fetched sources fail.
+func collectPageSources[S pageSource](ctx context.Context, location application.CollectLocation, sources []S, name string, collect func(context.Context, S) ([]application.Event, error)) ([]application.Event, error) {
+	allEvents := []application.Event{}
+	errs := []error{}
+	fetched := 0
+	for _, source := range sources {
+		if venue := source.venue(); venue != nil && !location.Contains(*venue) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fetched++
+		events, err := collect(ctx, source)
+		if err != nil {
+			slog.Warn("Failed to collect "+name, "url", source.pageURL(), "error", err)
+			errs = append(errs, fmt.Errorf("%s %s: %w", name, source.pageURL(), err))
+			continue
+		}
+		allEvents = append(allEvents, events...)
+	}
+
+	if fetched > 0 && len(errs) == fetched {
+		return nil, errors.Join(errs...)
+	}
+
+	return allEvents, nil
+}
+
+// getPage requests the page and returns its body, to be closed by the caller
+func getPage(ctx context.Context, client *http.Client, pageURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error executing request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"math/rand/v2"
 	"time"
 
@@ -14,7 +15,7 @@ func NewRandomCollector() application.Collector {
 	return &randomCollector{}
 }
 
-func (c *randomCollector) Collect(location application.CollectLocation) ([]application.Event, error) {
+func (c *randomCollector) Collect(ctx context.Context, location application.CollectLocation) ([]application.Event, error) {
 	return []application.Event{
 		{
 			Name:   "Random Event 1",
@@ -1,6 +1,7 @@
 package collector_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/leorolland/sortir.in/pkg/application"
@@ -9,7 +10,7 @@ import (
 
 func TestRandomCollectorSuccess(t *testing.T) {
 	collector := collector.NewRandomCollector()
-	events, err := collector.Collect(application.CollectLocation{
+	events, err := collector.Collect(context.Background(), application.CollectLocation{
 		City: "Paris",
 		Lon: 2.3522,
 		Lat:  48.8566,
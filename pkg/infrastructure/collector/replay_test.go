@@ -0,0 +1,281 @@
+package collector_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+// record replaces the cassettes by the responses of the live APIs, update replaces the golden files by the collected events.
+// Recording then running the tests shows how the upstream formats drifted in the diff of the golden files.
+var (
+	record = flag.Bool("record", false, "record the cassettes from the live APIs")
+	update = flag.Bool("update", false, "update the golden files from the collected events")
+)
+
+// interaction is a request of a collector and its recorded response
+type interaction struct {
+	Method string `json:"method"`
+	// URL and RequestBody are matched but for their datedParams
+	URL         string          `json:"url"`
+	RequestBody json.RawMessage `json:"request_body,omitempty"`
+	Status      int             `json:"status"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// datedParams are the query params and request body fields holding the current date, which are not matched
+var datedParams = []string{"start", "end", "start_date", "end_date"}
+
+// cassette is a transport replaying the interactions recorded in testdata/cassettes, in order,
+// or recording them from the live APIs with -record
+type cassette struct {
+	t            *testing.T
+	path         string
+	interactions []interaction
+	next         int
+	mu           sync.Mutex
+}
+
+func newCassette(t *testing.T, name string) *cassette {
+	t.Helper()
+
+	c := &cassette{t: t, path: filepath.Join("testdata", "cassettes", name+".json")}
+	if *record {
+		t.Cleanup(c.save)
+		return c
+	}
+
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		t.Fatalf("failed to read cassette, record it with -record: %v", err)
+	}
+	if err := json.Unmarshal(content, &c.interactions); err != nil {
+		t.Fatalf("failed to parse cassette: %v", err)
+	}
+	t.Cleanup(func() {
+		if c.next != len(c.interactions) {
+			t.Errorf("expected %d requests, got %d", len(c.interactions), c.next)
+		}
+	})
+	return c
+}
+
+func (c *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *record {
+		return c.recordRoundTrip(req)
+	}
+
+	if c.next >= len(c.interactions) {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+	recorded := c.interactions[c.next]
+	c.next++
+
+	// Mismatches are reported by the test, as collectors may retry or only log failing requests
+	if err := recorded.match(req); err != nil {
+		c.t.Errorf("expected request %s %s, got %s %s: %v", recorded.Method, recorded.URL, req.Method, req.URL, err)
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: recorded.Status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(recorded.Body)),
+		Request:    req,
+	}, nil
+}
+
+// match checks that the request is the recorded one, ignoring its datedParams
+func (i interaction) match(req *http.Request) error {
+	recordedURL, err := url.Parse(i.URL)
+	if err != nil {
+		return err
+	}
+	if i.Method != req.Method || recordedURL.Host != req.URL.Host || recordedURL.Path != req.URL.Path {
+		return errors.New("different method or path")
+	}
+
+	recordedQuery, query := recordedURL.Query(), req.URL.Query()
+	for _, param := range datedParams {
+		recordedQuery.Del(param)
+		query.Del(param)
+	}
+	if recordedQuery.Encode() != query.Encode() {
+		return fmt.Errorf("different query %s", query.Encode())
+	}
+
+	if len(i.RequestBody) == 0 {
+		return nil
+	}
+	if req.GetBody == nil {
+		return errors.New("missing body")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var recordedFields, fields map[string]any
+	if err := json.Unmarshal(i.RequestBody, &recordedFields); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(body).Decode(&fields); err != nil {
+		return err
+	}
+	for _, param := range datedParams {
+		delete(recordedFields, param)
+		delete(fields, param)
+	}
+	if !reflect.DeepEqual(recordedFields, fields) {
+		return fmt.Errorf("different body fields %v", fields)
+	}
+	return nil
+}
+
+func (c *cassette) recordRoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := interaction{Method: req.Method, URL: req.URL.String()}
+	if req.Body != nil {
+		requestBody, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+		recorded.RequestBody = requestBody
+	}
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded.Status = resp.StatusCode
+	recorded.Body = body
+	if !json.Valid(body) {
+		recorded.Body, _ = json.Marshal(string(body))
+	}
+	c.interactions = append(c.interactions, recorded)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (c *cassette) save() {
+	content, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		c.t.Fatalf("failed to marshal cassette: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		c.t.Fatalf("failed to create cassettes directory: %v", err)
+	}
+	if err := os.WriteFile(c.path, append(content, '\n'), 0o644); err != nil {
+		c.t.Fatalf("failed to write cassette: %v", err)
+	}
+}
+
+// assertGolden compares the events with the golden file testdata/golden/<name>.json, printing the differing lines
+func assertGolden(t *testing.T, name string, events []application.Event) {
+	t.Helper()
+
+	// Dates are compared in UTC, whatever the time zone of the machine
+	normalized := make([]application.Event, len(events))
+	for i, event := range events {
+		event.Begin = event.Begin.UTC()
+		event.End = event.End.UTC()
+		normalized[i] = event
+	}
+	actual, err := json.MarshalIndent(normalized, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal events: %v", err)
+	}
+	actual = append(actual, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update || *record {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, write it with -update: %v", err)
+	}
+	if diff := lineDiff(string(expected), string(actual)); diff != "" {
+		t.Errorf("events differ from %s, update it with -update if expected:\n%s", path, diff)
+	}
+}
+
+// lineDiff lists the lines only found in expected (-) or actual (+), empty if they are equal
+func lineDiff(expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	var diff strings.Builder
+	for i := 0; i < max(len(expectedLines), len(actualLines)); i++ {
+		var e, a string
+		if i < len(expectedLines) {
+			e = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e == a {
+			continue
+		}
+		if i < len(expectedLines) && !slices.Contains(actualLines, e) {
+			fmt.Fprintf(&diff, "%d - %s\n", i+1, e)
+		}
+		if i < len(actualLines) && !slices.Contains(expectedLines, a) {
+			fmt.Fprintf(&diff, "%d + %s\n", i+1, a)
+		}
+	}
+	if diff.Len() == 0 {
+		return "lines are reordered"
+	}
+	return diff.String()
+}
+
+// replayedCollector builds a collector of type collectorType, making its requests through the cassette named name
+func replayedCollector(t *testing.T, collectorType, name string, options collector.Options) application.Collector {
+	t.Helper()
+
+	options.Transport = newCassette(t, name)
+	config := collector.Config{Collectors: []collector.CollectorConfig{{Name: name, Type: collectorType, Options: options}}}
+	c, err := config.Collector(name)
+	if err != nil {
+		t.Fatalf("failed to build collector: %v", err)
+	}
+	return c
+}
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// constructors maps collector types used in configuration files to their constructor
+var constructors = map[string]func(Options) application.Collector{
+	"allevents": newAllEventsCollector,
+	"bobine":    newBobineCollector,
+	"ics":       newICSCollector,
+	"jsonld":    newJSONLDCollector,
+	"paris":     newParisEventsCollector,
+}
+
+// Duration is a time.Duration written as a string in configuration files, e.g. "30m"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Schedule describes when a set of collectors runs, and for which cities
+type Schedule struct {
+	Name       string   `json:"name"`
+	Cron       string   `json:"cron"`
+	Collectors []string `json:"collectors"`
+	// Cities restricts the schedule to some cities, all enabled locations are collected when empty
+	Cities []string `json:"cities,omitempty"`
+	// Workers is the maximum amount of collectors running in parallel, they run sequentially if 0
+	Workers int `json:"workers,omitempty"`
+	// Timeout is the deadline of a whole run, no deadline if 0
+	Timeout Duration `json:"timeout,omitempty"`
+	// Budget is the maximum amount of requests collectors make during a run, retries included, no limit if 0
+	Budget int `json:"budget,omitempty"`
+	// Full makes incremental collectors collect all the events again on each run, e.g. for a nightly full resync
+	Full bool `json:"full,omitempty"`
+}
+
+type schedulesFile struct {
+	Schedules []Schedule `json:"schedules"`
+}
+
+// LoadSchedules reads and validates the schedules of a JSON file, running the collectors of config
+// for cities among locations
+func LoadSchedules(path string, config Config, locations []application.CollectLocation) ([]Schedule, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedules file: %w", err)
+	}
+
+	var file schedulesFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse schedules file: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i, schedule := range file.Schedules {
+		if err := schedule.Validate(config, locations); err != nil {
+			return nil, fmt.Errorf("invalid schedule %d: %w", i, err)
+		}
+		if names[schedule.Name] {
+			return nil, fmt.Errorf("invalid schedule %d: duplicated name %q", i, schedule.Name)
+		}
+		names[schedule.Name] = true
+	}
+
+	return file.Schedules, nil
+}
+
+// Validate checks the schedule, its collectors being enabled in config and its cities among locations
+func (s Schedule) Validate(config Config, locations []application.CollectLocation) error {
+	errs := []error{}
+
+	if strings.TrimSpace(s.Name) == "" {
+		errs = append(errs, errors.New("missing name"))
+	}
+
+	if _, err := cron.NewSchedule(s.Cron); err != nil {
+		errs = append(errs, fmt.Errorf("invalid cron expression %q: %w", s.Cron, err))
+	}
+
+	if len(s.Collectors) == 0 {
+		errs = append(errs, errors.New("missing collectors"))
+	}
+	enabled := config.Enabled()
+	for _, name := range s.Collectors {
+		if !slices.Contains(enabled, name) {
+			errs = append(errs, fmt.Errorf("unknown or disabled collector %q", name))
+		}
+	}
+
+	if err := validateCities(s.Cities, locations); err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.Workers < 0 {
+		errs = append(errs, errors.New("workers must be positive"))
+	}
+
+	if s.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must be positive"))
+	}
+
+	if s.Budget < 0 {
+		errs = append(errs, errors.New("budget must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Collector builds the collector running all the collectors of the schedule, as configured in config
+func (s Schedule) Collector(config Config) (application.Collector, error) {
+	collectors := make([]NamedCollector, 0, len(s.Collectors))
+	for _, name := range s.Collectors {
+		collector, err := config.Collector(name)
+		if err != nil {
+			return nil, err
+		}
+		collectors = append(collectors, collector)
+	}
+
+	if s.Workers > 0 {
+		return NewConcurrentCompositeCollector(s.Workers, collectors...), nil
+	}
+	return NewCompositeCollector(collectors...), nil
+}
+
+// Locations returns the locations collected by the schedule among locations, keeping their order
+func (s Schedule) Locations(locations []application.CollectLocation) []application.CollectLocation {
+	if len(s.Cities) == 0 {
+		return locations
+	}
+
+	scheduleLocations := []application.CollectLocation{}
+	for _, location := range locations {
+		if slices.Contains(s.Cities, location.City) {
+			scheduleLocations = append(scheduleLocations, location)
+		}
+	}
+	return scheduleLocations
+}
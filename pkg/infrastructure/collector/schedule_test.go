@@ -0,0 +1,75 @@
+package collector_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+)
+
+func writeSchedulesFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write schedules file: %v", err)
+	}
+	return path
+}
+
+func TestLoadSchedulesSuccess(t *testing.T) {
+	path := writeSchedulesFile(t, `{
+		"schedules": [
+			{"name": "paris", "cron": "0 * * * *", "collectors": ["paris", "allevents"], "cities": ["Paris"], "workers": 2, "timeout": "20m", "budget": 500},
+			{"name": "everywhere", "cron": "0 */6 * * *", "collectors": ["allevents", "bobine"], "full": true}
+		]
+	}`)
+
+	schedules, err := collector.LoadSchedules(path, collector.DefaultConfig(), applicationtest.FrenchCities())
+	if err != nil {
+		t.Fatalf("failed to load schedules: %v", err)
+	}
+	if len(schedules) != 2 {
+		t.Fatalf("expected 2 schedules, got %d", len(schedules))
+	}
+	if time.Duration(schedules[0].Timeout) != 20*time.Minute {
+		t.Errorf("expected a 20m timeout, got %v", time.Duration(schedules[0].Timeout))
+	}
+	if schedules[0].Budget != 500 {
+		t.Errorf("expected a budget of 500 requests, got %d", schedules[0].Budget)
+	}
+	if schedules[0].Full || !schedules[1].Full {
+		t.Errorf("expected only the second schedule to be full")
+	}
+	if len(schedules[0].Locations(applicationtest.FrenchCities())) != 1 {
+		t.Errorf("expected 1 location, got %d", len(schedules[0].Locations(applicationtest.FrenchCities())))
+	}
+	if len(schedules[1].Locations(applicationtest.FrenchCities())) != 30 {
+		t.Errorf("expected 30 locations, got %d", len(schedules[1].Locations(applicationtest.FrenchCities())))
+	}
+}
+
+func TestLoadSchedulesError(t *testing.T) {
+	testCases := map[string]string{
+		"when the cron expression is invalid": `{"schedules": [{"name": "a", "cron": "every hour", "collectors": ["paris"]}]}`,
+		"when a collector is unknown":         `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["foo"]}]}`,
+		"when a city is unknown":              `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["paris"], "cities": ["Atlantis"]}]}`,
+		"when a name is duplicated": `{"schedules": [
+			{"name": "a", "cron": "0 * * * *", "collectors": ["paris"]},
+			{"name": "a", "cron": "0 * * * *", "collectors": ["bobine"]}
+		]}`,
+		"when the timeout is invalid": `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["paris"], "timeout": "soon"}]}`,
+		"when the budget is negative": `{"schedules": [{"name": "a", "cron": "0 * * * *", "collectors": ["paris"], "budget": -1}]}`,
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := collector.LoadSchedules(writeSchedulesFile(t, content), collector.DefaultConfig(), applicationtest.FrenchCities()); err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+		})
+	}
+}
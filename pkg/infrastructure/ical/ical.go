@@ -0,0 +1,179 @@
+// Package ical encodes events into iCalendar (RFC 5545) calendars, to be imported or subscribed to by calendar apps,
+// and parses the calendars published by venues, expanding their recurring events.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const ContentType = "text/calendar; charset=utf-8"
+
+// maxLineOctets is the length above which content lines are folded
+const maxLineOctets = 75
+
+// Calendar is a set of events, published by ProdID
+type Calendar struct {
+	ProdID string
+	// Name is displayed by calendar apps subscribing to the calendar, if set
+	Name string
+	// RefreshInterval suggests subscribers how often to refresh the calendar, if set
+	RefreshInterval time.Duration
+	// Stamp is the date at which the calendar is generated
+	Stamp  time.Time
+	Events []Event
+}
+
+// Event is a VEVENT, whose empty properties are omitted
+type Event struct {
+	UID         string
+	Summary     string
+	Start       time.Time
+	End         time.Time
+	Location    string
+	Geo         *Geo
+	URL         string
+	Categories  []string
+	Description string
+	// RRule is the recurrence rule of the event, e.g. FREQ=WEEKLY;BYDAY=MO,WE, see Occurrences
+	RRule string
+	// ExDates are the starts of the occurrences excluded from the recurrence
+	ExDates []time.Time
+}
+
+type Geo struct {
+	Lat float64
+	Lon float64
+}
+
+// Encode writes the calendar, with CRLF line endings and lines folded at 75 octets
+func Encode(w io.Writer, calendar Calendar) error {
+	e := &encoder{w: w}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", escapeText(calendar.ProdID))
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		e.line("NAME", escapeText(calendar.Name))
+		e.line("X-WR-CALNAME", escapeText(calendar.Name))
+	}
+	if calendar.RefreshInterval > 0 {
+		e.line("REFRESH-INTERVAL;VALUE=DURATION", formatDuration(calendar.RefreshInterval))
+		e.line("X-PUBLISHED-TTL", formatDuration(calendar.RefreshInterval))
+	}
+
+	for _, event := range calendar.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", escapeText(event.UID))
+		e.line("DTSTAMP", formatDate(calendar.Stamp))
+		e.line("DTSTART", formatDate(event.Start))
+		e.line("DTEND", formatDate(event.End))
+		e.line("SUMMARY", escapeText(event.Summary))
+		if event.Location != "" {
+			e.line("LOCATION", escapeText(event.Location))
+		}
+		if event.Geo != nil {
+			e.line("GEO", strconv.FormatFloat(event.Geo.Lat, 'f', -1, 64)+";"+strconv.FormatFloat(event.Geo.Lon, 'f', -1, 64))
+		}
+		if event.URL != "" {
+			e.line("URL", event.URL)
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			e.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if event.Description != "" {
+			e.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.RRule != "" {
+			e.line("RRULE", event.RRule)
+		}
+		for _, exDate := range event.ExDates {
+			e.line("EXDATE", formatDate(exDate))
+		}
+		e.line("END", "VEVENT")
+	}
+
+	e.line("END", "VCALENDAR")
+
+	return e.err
+}
+
+// encoder writes content lines, keeping the first error
+type encoder struct {
+	w   io.Writer
+	err error
+}
+
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = io.WriteString(e.w, fold(name+":"+value)+"\r\n")
+}
+
+// fold splits a content line into lines of at most 75 octets, continuation lines starting with a space.
+// UTF-8 characters are never split.
+func fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	length := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if length+size > limit {
+			b.WriteString("\r\n ")
+			// the leading space counts in the length of continuation lines
+			limit = maxLineOctets - 1
+			length = 0
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	return b.String()
+}
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// formatDuration formats a duration to the second, e.g. PT6H
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := seconds / 3600; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := seconds % 60; s > 0 || seconds == 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
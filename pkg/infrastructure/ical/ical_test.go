@@ -0,0 +1,102 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	begin := time.Date(2025, 11, 23, 21, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	var b bytes.Buffer
+	err := Encode(&b, Calendar{
+		ProdID: "-//sortir.in//sortir.in//FR",
+		Stamp:  time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC),
+		Events: []Event{{
+			UID:         "abc@sortir.in",
+			Summary:     "Jazz, blues; et soul",
+			Start:       begin,
+			End:         begin.Add(2 * time.Hour),
+			Location:    "La Cave, 1 rue de Paris",
+			Geo:         &Geo{Lat: 48.8566, Lon: 2.3522},
+			URL:         "https://example.com/jazz",
+			Categories:  []string{"concert", "Jazz"},
+			Description: "Prix : 12 EUR\nSur place",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//sortir.in//sortir.in//FR",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:abc@sortir.in",
+		"DTSTAMP:20251120T100000Z",
+		"DTSTART:20251123T200000Z",
+		"DTEND:20251123T220000Z",
+		`SUMMARY:Jazz\, blues\; et soul`,
+		`LOCATION:La Cave\, 1 rue de Paris`,
+		"GEO:48.8566;2.3522",
+		"URL:https://example.com/jazz",
+		"CATEGORIES:concert,Jazz",
+		`DESCRIPTION:Prix : 12 EUR\nSur place`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestEncodeFeed(t *testing.T) {
+	var b bytes.Buffer
+	err := Encode(&b, Calendar{
+		ProdID:          "-//sortir.in//sortir.in//FR",
+		Name:            "Concerts",
+		RefreshInterval: 6 * time.Hour,
+		Stamp:           time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, line := range []string{"NAME:Concerts\r\n", "X-WR-CALNAME:Concerts\r\n", "REFRESH-INTERVAL;VALUE=DURATION:PT6H\r\n", "X-PUBLISHED-TTL:PT6H\r\n"} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "VEVENT") {
+		t.Errorf("Expected no event, got:\n%s", b.String())
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 60)
+
+	folded := fold(line)
+
+	lines := strings.Split(folded, "\r\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", lines)
+	}
+	// "SUMMARY:" and 33 two-octet characters fit in 75 octets
+	if lines[0] != "SUMMARY:"+strings.Repeat("é", 33) {
+		t.Errorf("Expected the first line to hold 33 characters, got %q", lines[0])
+	}
+	if lines[1] != " "+strings.Repeat("é", 27) {
+		t.Errorf("Expected the continuation line to hold the 27 other characters, got %q", lines[1])
+	}
+	for _, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Errorf("Expected lines of at most %d octets, got %d", maxLineOctets, len(l))
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// calendars name their time zones, which must be known even where the system has no time zone database
+	_ "time/tzdata"
+)
+
+// ParseError is an event which could not be parsed, the other events of the calendar being kept
+type ParseError struct {
+	UID string
+	Err error
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("event %s: %v", e.UID, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads the events of a calendar.
+// Dates without time zone, or with a time zone unknown to Go (e.g. Windows names), are read in location.
+// Events with an all-day start last until their DTEND date, or one day without it.
+// Invalid events are skipped, the returned error then joins their ParseErrors alongside the valid events.
+func Parse(r io.Reader, location *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var errs []error
+
+	var current *rawEvent
+	for _, line := range lines {
+		prop, err := parseProperty(line)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && prop.value == "VEVENT":
+			current = &rawEvent{}
+		case prop.name == "END" && prop.value == "VEVENT":
+			if current == nil {
+				continue
+			}
+			event, err := current.toEvent(location)
+			if err != nil {
+				errs = append(errs, ParseError{UID: current.uid, Err: err})
+			} else {
+				events = append(events, event)
+			}
+			current = nil
+		case current != nil:
+			current.properties = append(current.properties, prop)
+			if prop.name == "UID" {
+				current.uid = prop.value
+			}
+		}
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// unfold reads the content lines of a calendar, joining folded lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseProperty splits a content line into its name, parameters and value, e.g. DTSTART;TZID=Europe/Paris:20251123T200000
+func parseProperty(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+
+	// the value starts at the first colon which is not quoted in a parameter
+	quoted := false
+	valueStart := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			valueStart = i
+			break
+		}
+	}
+	if valueStart < 0 {
+		return property{}, fmt.Errorf("missing value in %q", line)
+	}
+
+	parts := strings.Split(line[:valueStart], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	prop.value = line[valueStart+1:]
+
+	return prop, nil
+}
+
+type rawEvent struct {
+	uid        string
+	properties []property
+}
+
+func (e rawEvent) toEvent(location *time.Location) (Event, error) {
+	event := Event{UID: e.uid}
+
+	var hasEnd, allDay bool
+	var duration *time.Duration
+	for _, prop := range e.properties {
+		var err error
+		switch prop.name {
+		case "SUMMARY":
+			event.Summary = unescapeText(prop.value)
+		case "DTSTART":
+			event.Start, allDay, err = parseDate(prop, location)
+		case "DTEND":
+			event.End, _, err = parseDate(prop, location)
+			hasEnd = true
+		case "DURATION":
+			var d time.Duration
+			d, err = parseDuration(prop.value)
+			duration = &d
+		case "LOCATION":
+			event.Location = unescapeText(prop.value)
+		case "GEO":
+			event.Geo, err = parseGeo(prop.value)
+		case "URL":
+			event.URL = prop.value
+		case "CATEGORIES":
+			for _, category := range splitText(prop.value) {
+				if category = strings.TrimSpace(category); category != "" {
+					event.Categories = append(event.Categories, category)
+				}
+			}
+		case "DESCRIPTION":
+			event.Description = unescapeText(prop.value)
+		case "RRULE":
+			event.RRule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				var exDate time.Time
+				exDate, _, err = parseDate(property{params: prop.params, value: value}, location)
+				if err != nil {
+					break
+				}
+				event.ExDates = append(event.ExDates, exDate)
+			}
+		}
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid %s: %w", prop.name, err)
+		}
+	}
+
+	if event.Start.IsZero() {
+		return Event{}, errors.New("missing DTSTART")
+	}
+
+	switch {
+	case hasEnd:
+	case duration != nil:
+		event.End = event.Start.Add(*duration)
+	case allDay:
+		event.End = event.Start.AddDate(0, 0, 1)
+	default:
+		event.End = event.Start
+	}
+
+	if event.End.Before(event.Start) {
+		return Event{}, errors.New("ends before it starts")
+	}
+
+	return event, nil
+}
+
+// parseDate reads a DATE or DATE-TIME value, and whether it is a DATE
+func parseDate(prop property, location *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+
+	if prop.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		date, err := time.ParseInLocation("20060102", value, location)
+		return date, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		date, err := time.Parse("20060102T150405Z", value)
+		return date, false, err
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			location = tz
+		}
+	}
+
+	date, err := time.ParseInLocation("20060102T150405", value, location)
+	return date, false, err
+}
+
+var durationRegexp = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads a DURATION value, e.g. PT1H30M or P2D
+func parseDuration(value string) (time.Duration, error) {
+	matches := durationRegexp.FindStringSubmatch(value)
+	if matches == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if matches[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+2])
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	if matches[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+func parseGeo(value string) (*Geo, error) {
+	latStr, lonStr, ok := strings.Cut(value, ";")
+	if !ok {
+		return nil, fmt.Errorf("expected lat;lon, got %q", value)
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Geo{Lat: lat, Lon: lon}, nil
+}
+
+// splitText splits a list of TEXT values on its unescaped commas
+func splitText(value string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescapeText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeText(value[start:]))
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
@@ -0,0 +1,101 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:jazz@example.com",
+		`SUMMARY:Jazz\, blues\; et soul`,
+		"DTSTART;TZID=Europe/Paris:20251123T210000",
+		"DURATION:PT1H30M",
+		`LOCATION:La Cave\, 1 rue de Paris`,
+		"GEO:48.8566;2.3522",
+		`CATEGORIES:concert,Jazz\, Blues`,
+		"DESCRIPTION:Prix : 12 EUR\\nSur place, une tr",
+		" ès longue description",
+		"RRULE:FREQ=WEEKLY;COUNT=4",
+		"EXDATE;TZID=Europe/Paris:20251130T210000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:market@example.com",
+		"SUMMARY:Marché",
+		"DTSTART;VALUE=DATE:20251124",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken@example.com",
+		"SUMMARY:Sans date",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := Parse(strings.NewReader(calendar), paris)
+
+	var parseErr ParseError
+	if !errors.As(err, &parseErr) || parseErr.UID != "broken@example.com" {
+		t.Errorf("Expected a parse error for the event without start, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	jazz := events[0]
+	if jazz.Summary != "Jazz, blues; et soul" || jazz.Location != "La Cave, 1 rue de Paris" {
+		t.Errorf("Expected unescaped texts, got %q, %q", jazz.Summary, jazz.Location)
+	}
+	if !jazz.Start.Equal(time.Date(2025, 11, 23, 20, 0, 0, 0, time.UTC)) || jazz.End.Sub(jazz.Start) != 90*time.Minute {
+		t.Errorf("Expected a 1h30 event at 21:00 in Paris, got %v to %v", jazz.Start, jazz.End)
+	}
+	if jazz.Geo == nil || *jazz.Geo != (Geo{Lat: 48.8566, Lon: 2.3522}) {
+		t.Errorf("Expected a geo, got %v", jazz.Geo)
+	}
+	if len(jazz.Categories) != 2 || jazz.Categories[1] != "Jazz, Blues" {
+		t.Errorf("Expected 2 categories, got %q", jazz.Categories)
+	}
+	if jazz.Description != "Prix : 12 EUR\nSur place, une très longue description" {
+		t.Errorf("Expected an unfolded description, got %q", jazz.Description)
+	}
+	if jazz.RRule != "FREQ=WEEKLY;COUNT=4" || len(jazz.ExDates) != 1 {
+		t.Errorf("Expected a recurrence with an exception, got %q, %v", jazz.RRule, jazz.ExDates)
+	}
+
+	market := events[1]
+	if !market.Start.Equal(time.Date(2025, 11, 24, 0, 0, 0, 0, paris)) || !market.End.Equal(time.Date(2025, 11, 25, 0, 0, 0, 0, paris)) {
+		t.Errorf("Expected an all-day event, got %v to %v", market.Start, market.End)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P2D":     48 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"P1DT12H": 36 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+	}
+
+	for value, expected := range testCases {
+		duration, err := parseDuration(value)
+		if err != nil || duration != expected {
+			t.Errorf("Expected %s to be %v, got %v, %v", value, expected, duration, err)
+		}
+	}
+
+	for _, value := range []string{"P", "PT", "1H", "PT1X"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("Expected %s to be invalid", value)
+		}
+	}
+}
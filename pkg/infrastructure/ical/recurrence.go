@@ -0,0 +1,263 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrenceDays bounds the days walked through to expand a recurrence, about 30 years
+const maxRecurrenceDays = 11000
+
+var ErrUnsupportedRRule = errors.New("unsupported recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// rrule is a recurrence rule, occurrences being days matching all of its parts
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+}
+
+// weekdayNum is a BYDAY part, e.g. -1FR for the last friday of the month, n being 0 for every friday
+type weekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+// Occurrences returns the occurrences of the event overlapping the from/to window, without recurrence rule.
+// Occurrences keep the time of day of the event in its time zone, across daylight saving changes.
+// DAILY, WEEKLY, MONTHLY and YEARLY rules are supported, with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH.
+func (e Event) Occurrences(from, to time.Time) ([]Event, error) {
+	overlaps := func(o Event) bool {
+		return !o.Start.After(to) && !o.End.Before(from)
+	}
+
+	if e.RRule == "" {
+		if overlaps(e) {
+			return []Event{e}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := parseRRule(e.RRule, e.Start.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	duration := e.End.Sub(e.Start)
+	start := e.Start
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+
+	var occurrences []Event
+	count := 0
+	for i := 0; i < maxRecurrenceDays; i++ {
+		day := startDay.AddDate(0, 0, i)
+		occurrenceStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+
+		if occurrenceStart.After(to) || (!rule.until.IsZero() && occurrenceStart.After(rule.until)) {
+			break
+		}
+		// the start of the event is always its first occurrence
+		if i > 0 && !rule.matches(day, startDay) {
+			continue
+		}
+
+		count++
+		if rule.count > 0 && count > rule.count {
+			break
+		}
+
+		if slices.ContainsFunc(e.ExDates, occurrenceStart.Equal) {
+			continue
+		}
+
+		occurrence := e
+		occurrence.Start = occurrenceStart
+		occurrence.End = occurrenceStart.Add(duration)
+		occurrence.RRule = ""
+		occurrence.ExDates = nil
+		if overlaps(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	return occurrences, nil
+}
+
+func parseRRule(value string, location *time.Location) (rrule, error) {
+	rule := rrule{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, _ := strings.Cut(part, "=")
+
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(val)
+			if err == nil && rule.interval < 1 {
+				err = errors.New("interval must be positive")
+			}
+		case "COUNT":
+			rule.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.until, _, err = parseDate(property{value: val}, location)
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				var weekday weekdayNum
+				weekday, err = parseWeekdayNum(day)
+				if err != nil {
+					break
+				}
+				rule.byDay = append(rule.byDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				var n int
+				n, err = strconv.Atoi(day)
+				if err != nil {
+					break
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				var n int
+				n, err = strconv.Atoi(month)
+				if err != nil {
+					break
+				}
+				rule.byMonth = append(rule.byMonth, time.Month(n))
+			}
+		case "WKST":
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnsupportedRRule, name)
+		}
+		if err != nil {
+			return rrule{}, fmt.Errorf("invalid RRULE %s: %w", value, err)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rrule{}, fmt.Errorf("%w: FREQ=%s", ErrUnsupportedRRule, rule.freq)
+	}
+
+	return rule, nil
+}
+
+func parseWeekdayNum(value string) (weekdayNum, error) {
+	if len(value) < 2 {
+		return weekdayNum{}, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	weekday, ok := weekdays[strings.ToUpper(value[len(value)-2:])]
+	if !ok {
+		return weekdayNum{}, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		if n, err = strconv.Atoi(prefix); err != nil {
+			return weekdayNum{}, fmt.Errorf("invalid weekday %q", value)
+		}
+	}
+
+	return weekdayNum{n: n, weekday: weekday}, nil
+}
+
+// matches reports whether day is an occurrence of the rule, days being UTC midnights
+func (r rrule) matches(day, startDay time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, day.Month()) {
+		return false
+	}
+
+	switch r.freq {
+	case "DAILY":
+		if daysBetween(startDay, day)%r.interval != 0 {
+			return false
+		}
+		return len(r.byDay) == 0 || r.matchesWeekday(day)
+	case "WEEKLY":
+		if weeksBetween(startDay, day)%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Weekday() == startDay.Weekday()
+		}
+		return r.matchesWeekday(day)
+	case "MONTHLY":
+		if monthsBetween(startDay, day)%r.interval != 0 {
+			return false
+		}
+		return r.matchesDayOfMonth(day, startDay)
+	case "YEARLY":
+		if (day.Year()-startDay.Year())%r.interval != 0 {
+			return false
+		}
+		if len(r.byMonth) == 0 && day.Month() != startDay.Month() {
+			return false
+		}
+		return r.matchesDayOfMonth(day, startDay)
+	}
+	return false
+}
+
+// matchesWeekday reports whether day is one of the BYDAY weekdays, ignoring their ordinals
+func (r rrule) matchesWeekday(day time.Time) bool {
+	return slices.ContainsFunc(r.byDay, func(w weekdayNum) bool { return w.weekday == day.Weekday() })
+}
+
+// matchesDayOfMonth applies BYDAY (e.g. 2TU, -1FR) or BYMONTHDAY within the month of day,
+// the day of the month of the start being used without them
+func (r rrule) matchesDayOfMonth(day, startDay time.Time) bool {
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	if len(r.byDay) > 0 {
+		// nth weekday of the month, from its start and from its end
+		nth := (day.Day()-1)/7 + 1
+		nthFromEnd := -((daysInMonth-day.Day())/7 + 1)
+		return slices.ContainsFunc(r.byDay, func(w weekdayNum) bool {
+			return w.weekday == day.Weekday() && (w.n == 0 || w.n == nth || w.n == nthFromEnd)
+		})
+	}
+
+	if len(r.byMonthDay) > 0 {
+		return slices.ContainsFunc(r.byMonthDay, func(n int) bool {
+			return n == day.Day() || n == day.Day()-daysInMonth-1
+		})
+	}
+
+	return day.Day() == startDay.Day()
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// weeksBetween counts the weeks between two days, weeks starting on monday
+func weeksBetween(a, b time.Time) int {
+	mondayOf := func(t time.Time) time.Time {
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+	return daysBetween(mondayOf(a), mondayOf(b)) / 7
+}
+
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
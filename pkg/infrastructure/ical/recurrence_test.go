@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+
+	// a wednesday, before the daylight saving time ends on october 26th
+	start := time.Date(2025, 10, 1, 20, 30, 0, 0, paris)
+	from := start
+	to := start.AddDate(0, 2, 0)
+
+	testCases := map[string]struct {
+		rrule    string
+		exDates  []time.Time
+		expected []string
+	}{
+		"without recurrence": {
+			expected: []string{"2025-10-01"},
+		},
+		"daily with count": {
+			rrule:    "FREQ=DAILY;COUNT=3",
+			expected: []string{"2025-10-01", "2025-10-02", "2025-10-03"},
+		},
+		"every other day until": {
+			rrule:    "FREQ=DAILY;INTERVAL=2;UNTIL=20251006T000000Z",
+			expected: []string{"2025-10-01", "2025-10-03", "2025-10-05"},
+		},
+		"weekly on several days with an exception": {
+			rrule:    "FREQ=WEEKLY;BYDAY=WE,FR;COUNT=4",
+			exDates:  []time.Time{time.Date(2025, 10, 3, 20, 30, 0, 0, paris)},
+			expected: []string{"2025-10-01", "2025-10-08", "2025-10-10"},
+		},
+		"every other week": {
+			rrule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			expected: []string{"2025-10-01", "2025-10-15", "2025-10-29"},
+		},
+		"monthly on the last friday": {
+			rrule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			expected: []string{"2025-10-01", "2025-10-31", "2025-11-28"},
+		},
+		"monthly on the second tuesday": {
+			rrule:    "FREQ=MONTHLY;BYDAY=2TU",
+			expected: []string{"2025-10-01", "2025-10-14", "2025-11-11"},
+		},
+		"monthly on the last day": {
+			rrule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			expected: []string{"2025-10-01", "2025-10-31", "2025-11-30"},
+		},
+		"yearly": {
+			rrule:    "FREQ=YEARLY",
+			expected: []string{"2025-10-01"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			event := Event{Start: start, End: start.Add(2 * time.Hour), RRule: testCase.rrule, ExDates: testCase.exDates}
+
+			occurrences, err := event.Occurrences(from, to)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			days := []string{}
+			for _, occurrence := range occurrences {
+				days = append(days, occurrence.Start.Format(time.DateOnly))
+				if occurrence.Start.Hour() != 20 || occurrence.Start.Minute() != 30 {
+					t.Errorf("Expected occurrences at 20:30 in Paris, got %v", occurrence.Start)
+				}
+				if occurrence.End.Sub(occurrence.Start) != 2*time.Hour {
+					t.Errorf("Expected occurrences to last 2 hours, got %v", occurrence.End.Sub(occurrence.Start))
+				}
+				if occurrence.RRule != "" {
+					t.Errorf("Expected occurrences without recurrence, got %q", occurrence.RRule)
+				}
+			}
+			if len(days) != len(testCase.expected) {
+				t.Fatalf("Expected %v, got %v", testCase.expected, days)
+			}
+			for i := range days {
+				if days[i] != testCase.expected[i] {
+					t.Errorf("Expected %v, got %v", testCase.expected, days)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestOccurrencesWithinWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := Event{Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY"}
+
+	from := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+	occurrences, err := event.Occurrences(from, from.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// the occurrence in progress at from is kept
+	if len(occurrences) != 3 || !occurrences[0].Start.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 3 occurrences from june 1st, got %v", occurrences)
+	}
+}
+
+func TestOccurrencesUnsupported(t *testing.T) {
+	start := time.Date(2025, 10, 1, 20, 30, 0, 0, time.UTC)
+
+	for _, rrule := range []string{"FREQ=HOURLY", "FREQ=DAILY;BYSETPOS=1", "FREQ=WEEKLY;BYDAY=XX"} {
+		event := Event{Start: start, End: start, RRule: rrule}
+		if _, err := event.Occurrences(start, start.AddDate(0, 1, 0)); err == nil {
+			t.Errorf("Expected %s to be invalid", rrule)
+		}
+	}
+
+	event := Event{Start: start, End: start, RRule: "FREQ=SECONDLY"}
+	if _, err := event.Occurrences(start, start.AddDate(0, 1, 0)); !errors.Is(err, ErrUnsupportedRRule) {
+		t.Errorf("Expected an unsupported rule error, got %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+// Package jsonld extracts the schema.org events embedded as JSON-LD in web pages.
+package jsonld
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a schema.org Event, or one of its subtypes such as MusicEvent or TheaterEvent
+type Event struct {
+	// Types are the @type of the event, e.g. MusicEvent
+	Types []string
+	Name  string
+	URL   string
+	Image string
+	Start time.Time
+	End   time.Time
+	// Status is the eventStatus without its schema.org prefix, e.g. EventCancelled, empty if not set
+	Status string
+	// AttendanceMode is the eventAttendanceMode without its schema.org prefix, e.g. OnlineEventAttendanceMode
+	AttendanceMode string
+	Location       *Place
+	Offers         []Offer
+	Keywords       []string
+}
+
+// Place is the physical location of an event
+type Place struct {
+	Name    string
+	Address string
+	Geo     *Geo
+}
+
+type Geo struct {
+	Lat float64
+	Lon float64
+}
+
+type Offer struct {
+	Price    *float64
+	Currency string
+}
+
+// ExtractError is an event which could not be read, the other events of the page being kept
+type ExtractError struct {
+	Name string
+	Err  error
+}
+
+func (e ExtractError) Error() string {
+	return fmt.Sprintf("event %q: %v", e.Name, e.Err)
+}
+
+func (e ExtractError) Unwrap() error {
+	return e.Err
+}
+
+// MaxPageSize is the size above which pages are not read, agendas being far smaller
+const MaxPageSize = 10 << 20
+
+// ErrPageTooLarge is returned for pages larger than MaxPageSize
+var ErrPageTooLarge = fmt.Errorf("page larger than %d bytes", MaxPageSize)
+
+var scriptRegexp = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// Extract reads the events of the JSON-LD scripts of an HTML page, including those of @graph and nested in other nodes.
+// Dates without offset are read in location, events with a date-only end last until the end of that day.
+// Invalid events are skipped, the returned error then joins their ExtractErrors alongside the valid events.
+// Scripts which are not valid JSON are ignored, as pages often embed broken ones.
+func Extract(r io.Reader, location *time.Location) ([]Event, error) {
+	page, err := io.ReadAll(io.LimitReader(r, MaxPageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(page) > MaxPageSize {
+		return nil, ErrPageTooLarge
+	}
+
+	var events []Event
+	var errs []error
+	for _, match := range scriptRegexp.FindAllSubmatch(page, -1) {
+		var document any
+		if err := json.Unmarshal(bytes.TrimSpace(match[1]), &document); err != nil {
+			continue
+		}
+
+		for _, node := range eventNodes(document) {
+			event, err := toEvent(node, location)
+			if err != nil {
+				errs = append(errs, ExtractError{Name: text(node["name"]), Err: err})
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// eventNodes walks the document for the nodes typed as events
+func eventNodes(document any) []map[string]any {
+	var nodes []map[string]any
+
+	switch value := document.(type) {
+	case []any:
+		for _, item := range value {
+			nodes = append(nodes, eventNodes(item)...)
+		}
+	case map[string]any:
+		if isEvent(types(value)) {
+			return append(nodes, value)
+		}
+		// in a stable order, maps being unordered
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			nodes = append(nodes, eventNodes(value[key])...)
+		}
+	}
+
+	return nodes
+}
+
+// isEvent reports whether one of the types is Event or one of its subtypes, all named *Event but Festival
+func isEvent(types []string) bool {
+	for _, t := range types {
+		if strings.HasSuffix(t, "Event") || t == "Festival" {
+			return true
+		}
+	}
+	return false
+}
+
+func toEvent(node map[string]any, location *time.Location) (Event, error) {
+	event := Event{
+		Types:          types(node),
+		Name:           strings.TrimSpace(text(node["name"])),
+		URL:            text(node["url"]),
+		Image:          text(node["image"]),
+		Status:         enumeration(text(node["eventStatus"])),
+		AttendanceMode: enumeration(text(node["eventAttendanceMode"])),
+		Location:       place(node["location"]),
+		Offers:         offers(node["offers"]),
+		Keywords:       keywords(node["keywords"]),
+	}
+
+	if event.Name == "" {
+		return Event{}, errors.New("missing name")
+	}
+
+	start, _, err := parseDate(text(node["startDate"]), location)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid startDate: %w", err)
+	}
+	event.Start = start
+
+	event.End = event.Start
+	if endDate := text(node["endDate"]); endDate != "" {
+		end, dateOnly, err := parseDate(endDate, location)
+		if err != nil {
+			return Event{}, fmt.Errorf("invalid endDate: %w", err)
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		event.End = end
+	}
+
+	if event.End.Before(event.Start) {
+		return Event{}, errors.New("ends before it starts")
+	}
+
+	return event, nil
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04Z07:00", "2006-01-02T15:04Z0700"}
+
+var localDateLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// parseDate reads an ISO 8601 date, and whether it is a date without time
+func parseDate(value string, location *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, errors.New("missing date")
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		return date, true, nil
+	}
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, false, nil
+		}
+	}
+	for _, layout := range localDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date, false, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("unknown date format %q", value)
+}
+
+// types returns the @type of a node, which is either a string or a list of strings
+func types(node map[string]any) []string {
+	var types []string
+	for _, t := range list(node["@type"]) {
+		if s := enumeration(text(t)); s != "" {
+			types = append(types, s)
+		}
+	}
+	return types
+}
+
+// enumeration strips the schema.org prefix of a value, e.g. https://schema.org/EventCancelled
+func enumeration(value string) string {
+	if i := strings.LastIndexAny(value, "/:"); i >= 0 {
+		return value[i+1:]
+	}
+	return value
+}
+
+// list returns the values of a property, which may be a single value or an array
+func list(value any) []any {
+	if values, ok := value.([]any); ok {
+		return values
+	}
+	if value == nil {
+		return nil
+	}
+	return []any{value}
+}
+
+// text returns a property as a string, the first one of an array, the @id or url of a node, or a number
+func text(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	case map[string]any:
+		if url := text(v["url"]); url != "" {
+			return url
+		}
+		return text(v["@id"])
+	}
+	return ""
+}
+
+// number reads a number written as a number or a string, e.g. "12,50"
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", "."), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// place returns the first physical place of a location, which may also be a virtual location or a plain address
+func place(value any) *Place {
+	for _, item := range list(value) {
+		switch v := item.(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return &Place{Address: v}
+			}
+		case map[string]any:
+			if slices.Contains(types(v), "VirtualLocation") {
+				continue
+			}
+			p := &Place{
+				Name:    strings.TrimSpace(text(v["name"])),
+				Address: address(v["address"]),
+				Geo:     geo(v["geo"]),
+			}
+			if p.Geo == nil {
+				// some pages set the coordinates on the place itself
+				p.Geo = geo(v)
+			}
+			return p
+		}
+	}
+	return nil
+}
+
+// address formats a PostalAddress, e.g. "4 quai des Étroits, 69005 Lyon"
+func address(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		locality := strings.TrimSpace(text(v["postalCode"]) + " " + text(v["addressLocality"]))
+		parts := []string{}
+		for _, part := range []string{text(v["streetAddress"]), locality} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+func geo(value any) *Geo {
+	node, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	lat, latOK := number(node["latitude"])
+	lon, lonOK := number(node["longitude"])
+	if !latOK || !lonOK || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil
+	}
+	return &Geo{Lat: lat, Lon: lon}
+}
+
+// offers reads the price of each offer, the lowest price of aggregate offers
+func offers(value any) []Offer {
+	var offers []Offer
+	for _, item := range list(value) {
+		node, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		offer := Offer{Currency: text(node["priceCurrency"])}
+		if price, ok := number(node["price"]); ok {
+			offer.Price = &price
+		} else if price, ok := number(node["lowPrice"]); ok {
+			offer.Price = &price
+		}
+		offers = append(offers, offer)
+	}
+	return offers
+}
+
+// keywords reads keywords, written either as a list or as comma separated values
+func keywords(value any) []string {
+	var keywords []string
+	for _, item := range list(value) {
+		for _, keyword := range strings.Split(text(item), ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords = append(keywords, keyword)
+			}
+		}
+	}
+	return keywords
+}
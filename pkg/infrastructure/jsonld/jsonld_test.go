@@ -0,0 +1,92 @@
+package jsonld
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+
+	page := `<html><head>
+<script type='application/ld+json'>[
+  {"@context": "https://schema.org", "@type": ["ScreeningEvent", "Event"], "name": "Le Voyage dans la Lune",
+   "startDate": "2025-11-23T20:00", "eventStatus": "EventRescheduled",
+   "location": ["Cinéma Comoedia, 13 avenue Berthelot, Lyon"]},
+  {"@type": "WebPage", "mainEntity": {"@type": "Festival", "name": "Nuits sonores", "startDate": "2025-05-13", "endDate": "2025-05-18",
+   "location": {"@type": "Place", "name": "Les Grandes Locos", "latitude": 45.7296, "longitude": 4.8586},
+   "offers": {"@type": "Offer", "price": 45, "priceCurrency": "EUR"}}},
+  {"@type": "MusicEvent", "name": "Sans fin", "startDate": "2025-11-23T22:00:00Z", "endDate": "2025-11-23T21:00:00Z"}
+]</script>
+<script type="text/javascript">var event = {"@type": "MusicEvent"};</script>
+</head></html>`
+
+	events, err := Extract(strings.NewReader(page), paris)
+
+	var extractErr ExtractError
+	if !errors.As(err, &extractErr) || extractErr.Name != "Sans fin" {
+		t.Errorf("Expected an extract error for the event ending before it starts, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+
+	screening := events[0]
+	if len(screening.Types) != 2 || screening.Types[0] != "ScreeningEvent" {
+		t.Errorf("Expected the types of the event, got %q", screening.Types)
+	}
+	if !screening.Start.Equal(time.Date(2025, 11, 23, 20, 0, 0, 0, paris)) || !screening.End.Equal(screening.Start) {
+		t.Errorf("Expected a local start without end, got %v to %v", screening.Start, screening.End)
+	}
+	if screening.Status != "EventRescheduled" {
+		t.Errorf("Expected the status of the event, got %q", screening.Status)
+	}
+	if screening.Location == nil || screening.Location.Address != "Cinéma Comoedia, 13 avenue Berthelot, Lyon" || screening.Location.Geo != nil {
+		t.Errorf("Expected an address only location, got %+v", screening.Location)
+	}
+
+	festival := events[1]
+	if !festival.End.Equal(time.Date(2025, 5, 19, 0, 0, 0, 0, paris)) {
+		t.Errorf("Expected the festival to last until the end of its last day, got %v", festival.End)
+	}
+	if festival.Location == nil || festival.Location.Geo == nil || *festival.Location.Geo != (Geo{Lat: 45.7296, Lon: 4.8586}) {
+		t.Errorf("Expected the coordinates of the place, got %+v", festival.Location)
+	}
+	if len(festival.Offers) != 1 || festival.Offers[0].Price == nil || *festival.Offers[0].Price != 45 {
+		t.Errorf("Expected a 45 EUR offer, got %+v", festival.Offers)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	testCases := map[string]time.Time{
+		"2025-11-23T20:00:00+01:00": time.Date(2025, 11, 23, 19, 0, 0, 0, time.UTC),
+		"2025-11-23T20:00:00+0100":  time.Date(2025, 11, 23, 19, 0, 0, 0, time.UTC),
+		"2025-11-23T20:00Z":         time.Date(2025, 11, 23, 20, 0, 0, 0, time.UTC),
+		"2025-11-23T20:00:00":       time.Date(2025, 11, 23, 20, 0, 0, 0, time.UTC),
+		"2025-11-23 20:00":          time.Date(2025, 11, 23, 20, 0, 0, 0, time.UTC),
+	}
+
+	for value, expected := range testCases {
+		date, _, err := parseDate(value, time.UTC)
+		if err != nil || !date.Equal(expected) {
+			t.Errorf("Expected %s to be %v, got %v, %v", value, expected, date, err)
+		}
+	}
+
+	if _, _, err := parseDate("23/11/2025", time.UTC); err == nil {
+		t.Errorf("Expected an unknown date format to be invalid")
+	}
+}
+
+func TestExtractPageTooLarge(t *testing.T) {
+	page := strings.NewReader(strings.Repeat(" ", MaxPageSize+1))
+
+	if _, err := Extract(page, time.UTC); !errors.Is(err, ErrPageTooLarge) {
+		t.Errorf("Expected ErrPageTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package mvt encodes point features into Mapbox Vector Tiles (version 2.1 of the specification),
+// writing the protocol buffers wire format by hand as only a handful of messages are needed.
+package mvt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const ContentType = "application/vnd.mapbox-vector-tile"
+
+// Layer is a named set of features, located in a grid of Extent units per side
+type Layer struct {
+	Name     string
+	Extent   uint32
+	Features []Feature
+}
+
+// Feature is a point located from the top left corner of its tile.
+// Properties values are strings, bools, integers or floats.
+type Feature struct {
+	ID         uint64
+	X          int
+	Y          int
+	Properties map[string]any
+}
+
+// protocol buffers field numbers of vector_tile.proto
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueSint   = 6
+	valueBool   = 7
+
+	geomTypePoint = 1
+	commandMoveTo = 1
+)
+
+// protocol buffers wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// Encode returns the tile made of the layers.
+// It fails if a property value has an unsupported type.
+func Encode(layers ...Layer) ([]byte, error) {
+	var tile []byte
+	for _, layer := range layers {
+		encoded, err := encodeLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+		}
+		tile = appendBytes(tile, tileLayers, encoded)
+	}
+	return tile, nil
+}
+
+func encodeLayer(layer Layer) ([]byte, error) {
+	var keys []string
+	keyIndexes := map[string]int{}
+	var values [][]byte
+	valueIndexes := map[string]int{}
+
+	var features []byte
+	for _, feature := range layer.Features {
+		// sort properties so that a same tile is always encoded the same way
+		names := make([]string, 0, len(feature.Properties))
+		for name := range feature.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		tags := make([]uint64, 0, 2*len(names))
+		for _, name := range names {
+			keyIndex, ok := keyIndexes[name]
+			if !ok {
+				keyIndex = len(keys)
+				keyIndexes[name] = keyIndex
+				keys = append(keys, name)
+			}
+
+			value, err := encodeValue(feature.Properties[name])
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", name, err)
+			}
+			valueIndex, ok := valueIndexes[string(value)]
+			if !ok {
+				valueIndex = len(values)
+				valueIndexes[string(value)] = valueIndex
+				values = append(values, value)
+			}
+
+			tags = append(tags, uint64(keyIndex), uint64(valueIndex))
+		}
+
+		var encoded []byte
+		if feature.ID != 0 {
+			encoded = appendVarint(encoded, featureID, feature.ID)
+		}
+		encoded = appendPacked(encoded, featureTags, tags)
+		encoded = appendVarint(encoded, featureType, geomTypePoint)
+		encoded = appendPacked(encoded, featureGeometry, []uint64{
+			command(commandMoveTo, 1),
+			zigzag(int64(feature.X)),
+			zigzag(int64(feature.Y)),
+		})
+
+		features = appendBytes(features, layerFeatures, encoded)
+	}
+
+	var encoded []byte
+	encoded = appendVarint(encoded, layerVersion, 2)
+	encoded = appendBytes(encoded, layerName, []byte(layer.Name))
+	encoded = append(encoded, features...)
+	for _, key := range keys {
+		encoded = appendBytes(encoded, layerKeys, []byte(key))
+	}
+	for _, value := range values {
+		encoded = appendBytes(encoded, layerValues, value)
+	}
+	encoded = appendVarint(encoded, layerExtent, uint64(layer.Extent))
+
+	return encoded, nil
+}
+
+// encodeValue returns the Value message holding v
+func encodeValue(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return appendBytes(nil, valueString, []byte(v)), nil
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		return appendVarint(nil, valueBool, b), nil
+	case int:
+		return appendVarint(nil, valueSint, zigzag(int64(v))), nil
+	case int64:
+		return appendVarint(nil, valueSint, zigzag(v)), nil
+	case float64:
+		encoded := appendKey(nil, valueDouble, wireFixed64)
+		return binary.LittleEndian.AppendUint64(encoded, math.Float64bits(v)), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// command returns a geometry command integer, repeated count times
+func command(id, count uint64) uint64 {
+	return id&0x7 | count<<3
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func appendKey(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarint(b []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendKey(b, field, wireVarint), v)
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(appendKey(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendPacked(b []byte, field int, vs []uint64) []byte {
+	var packed []byte
+	for _, v := range vs {
+		packed = binary.AppendUvarint(packed, v)
+	}
+	return appendBytes(b, field, packed)
+}
@@ -0,0 +1,184 @@
+package mvt
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// field is a decoded protocol buffers field, holding either a varint, fixed64 or bytes
+type field struct {
+	number int
+	varint uint64
+	bytes  []byte
+}
+
+func decode(t *testing.T, b []byte) []field {
+	t.Helper()
+
+	var fields []field
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid key in %v", b)
+		}
+		b = b[n:]
+
+		f := field{number: int(key >> 3)}
+		switch key & 0x7 {
+		case wireVarint:
+			f.varint, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("invalid varint in %v", b)
+			}
+			b = b[n:]
+		case wireFixed64:
+			f.varint = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || int(length) > len(b[n:]) {
+				t.Fatalf("invalid length in %v", b)
+			}
+			f.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&0x7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func decodePacked(t *testing.T, b []byte) []uint64 {
+	t.Helper()
+
+	var vs []uint64
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("invalid packed varint in %v", b)
+		}
+		vs = append(vs, v)
+		b = b[n:]
+	}
+	return vs
+}
+
+func TestEncodeEmpty(t *testing.T) {
+	tile, err := Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tile) != 0 {
+		t.Errorf("Expected an empty tile, got %v", tile)
+	}
+}
+
+func TestEncodePoints(t *testing.T) {
+	tile, err := Encode(Layer{
+		Name:   "pins",
+		Extent: 4096,
+		Features: []Feature{
+			{ID: 1, X: 10, Y: 20, Properties: map[string]any{"kind": "concert", "amount": 3}},
+			{X: -5, Y: 4100, Properties: map[string]any{"kind": "concert", "amount": 1, "free": true, "price": 2.5}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	layers := decode(t, tile)
+	if len(layers) != 1 || layers[0].number != tileLayers {
+		t.Fatalf("Expected a single layer, got %v", layers)
+	}
+
+	var name string
+	var version, extent uint64
+	var keys []string
+	var values [][]field
+	var features [][]field
+	for _, f := range decode(t, layers[0].bytes) {
+		switch f.number {
+		case layerName:
+			name = string(f.bytes)
+		case layerVersion:
+			version = f.varint
+		case layerExtent:
+			extent = f.varint
+		case layerKeys:
+			keys = append(keys, string(f.bytes))
+		case layerValues:
+			values = append(values, decode(t, f.bytes))
+		case layerFeatures:
+			features = append(features, decode(t, f.bytes))
+		}
+	}
+
+	if name != "pins" || version != 2 || extent != 4096 {
+		t.Errorf("Expected layer pins v2 of extent 4096, got %s v%d of extent %d", name, version, extent)
+	}
+	if expected := []string{"amount", "kind", "free", "price"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, keys)
+	}
+
+	// values are deduplicated: 3, concert, 1, true, 2.5
+	expectedValues := [][]field{
+		{{number: valueSint, varint: zigzag(3)}},
+		{{number: valueString, bytes: []byte("concert")}},
+		{{number: valueSint, varint: zigzag(1)}},
+		{{number: valueBool, varint: 1}},
+		{{number: valueDouble, varint: math.Float64bits(2.5)}},
+	}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("Expected values %v, got %v", expectedValues, values)
+	}
+
+	if len(features) != 2 {
+		t.Fatalf("Expected 2 features, got %d", len(features))
+	}
+
+	expectedFeatures := []struct {
+		id       uint64
+		tags     []uint64
+		geometry []uint64
+	}{
+		{id: 1, tags: []uint64{0, 0, 1, 1}, geometry: []uint64{9, zigzag(10), zigzag(20)}},
+		{id: 0, tags: []uint64{0, 2, 2, 3, 1, 1, 3, 4}, geometry: []uint64{9, zigzag(-5), zigzag(4100)}},
+	}
+	for i, feature := range features {
+		var id, geomType uint64
+		var tags, geometry []uint64
+		for _, f := range feature {
+			switch f.number {
+			case featureID:
+				id = f.varint
+			case featureType:
+				geomType = f.varint
+			case featureTags:
+				tags = decodePacked(t, f.bytes)
+			case featureGeometry:
+				geometry = decodePacked(t, f.bytes)
+			}
+		}
+
+		expected := expectedFeatures[i]
+		if id != expected.id || geomType != geomTypePoint {
+			t.Errorf("Expected point feature %d, got feature %d of type %d", expected.id, id, geomType)
+		}
+		if !reflect.DeepEqual(tags, expected.tags) {
+			t.Errorf("Expected tags %v, got %v", expected.tags, tags)
+		}
+		if !reflect.DeepEqual(geometry, expected.geometry) {
+			t.Errorf("Expected geometry %v, got %v", expected.geometry, geometry)
+		}
+	}
+}
+
+func TestEncodeUnsupportedValue(t *testing.T) {
+	_, err := Encode(Layer{Name: "pins", Features: []Feature{{Properties: map[string]any{"kinds": []string{}}}}})
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
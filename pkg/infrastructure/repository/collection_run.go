@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type collectionRunRepository struct {
+	db DBGetter
+}
+
+func NewCollectionRunRepository(db DBGetter) collectionRunRepository {
+	return collectionRunRepository{db: db}
+}
+
+// SaveRun stores the totals of the run along the details of its cities and collectors
+func (r collectionRunRepository) SaveRun(ctx context.Context, run application.CollectionRun) error {
+	citiesJSON, err := json.Marshal(run.Locations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cities: %w", err)
+	}
+
+	collectors := run.Collectors()
+	collectorsJSON, err := json.Marshal(collectors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collectors: %w", err)
+	}
+
+	invalid, failures := 0, 0
+	for _, collector := range collectors {
+		invalid += collector.Invalid
+		failures += collector.Errors
+	}
+
+	startedAt, err := types.ParseDateTime(run.StartedAt)
+	if err != nil {
+		return err
+	}
+	endedAt, err := types.ParseDateTime(run.EndedAt)
+	if err != nil {
+		return err
+	}
+
+	totals := run.Totals()
+	_, err = r.db.Get().Insert("collection_runs", dbx.Params{
+		"name":              run.Name,
+		"started_at":        startedAt.String(),
+		"ended_at":          endedAt.String(),
+		"locations":         len(run.Locations),
+		"succeeded":         run.Succeeded(),
+		"events":            totals.Events,
+		"invalid":           invalid,
+		"inserted":          totals.Inserted,
+		"updated":           totals.Updated,
+		"unchanged":         totals.Unchanged,
+		"skipped":           totals.Skipped,
+		"failed":            totals.Failed,
+		"errors":            failures,
+		"cache_hits":        run.Cache.Hits,
+		"cache_revalidated": run.Cache.Revalidated,
+		"cache_misses":      run.Cache.Misses,
+		"cities":            citiesJSON,
+		"collectors":        collectorsJSON,
+		"error":             run.Error,
+		"created":           types.NowDateTime().String(),
+	}).WithContext(ctx).Execute()
+	return err
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type cursorRepository struct {
+	db DBGetter
+}
+
+func NewCursorRepository(db DBGetter) cursorRepository {
+	return cursorRepository{db: db}
+}
+
+func (r cursorRepository) Cursors(ctx context.Context, city string) (map[string]string, error) {
+	var rows []struct {
+		Source string `db:"source"`
+		Cursor string `db:"cursor"`
+	}
+
+	err := r.db.Get().Select("source", "cursor").From("collector_cursors").
+		Where(dbx.HashExp{"city": city}).
+		WithContext(ctx).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	cursors := make(map[string]string, len(rows))
+	for _, row := range rows {
+		cursors[row.Source] = row.Cursor
+	}
+	return cursors, nil
+}
+
+// SaveCursors inserts or replaces the cursor of each source, in a single transaction
+func (r cursorRepository) SaveCursors(ctx context.Context, city string, cursors map[string]string) error {
+	return r.db.RunInTransaction(func(tx dbx.Builder) error {
+		now := types.NowDateTime().String()
+		for source, cursor := range cursors {
+			_, err := tx.NewQuery(`
+				INSERT INTO collector_cursors (source, city, cursor, created, updated)
+				VALUES ({:source}, {:city}, {:cursor}, {:now}, {:now})
+				ON CONFLICT (city, source) DO UPDATE SET cursor = excluded.cursor, updated = excluded.updated
+			`).Bind(dbx.Params{"source": source, "city": city, "cursor": cursor, "now": now}).WithContext(ctx).Execute()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -3,10 +3,13 @@ package repository
 import (
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 )
 
 type DBGetter interface {
 	Get() dbx.Builder
+	// RunInTransaction runs fn in a transaction, committed if fn returns nil
+	RunInTransaction(fn func(tx dbx.Builder) error) error
 }
 
 type dbGetter struct {
@@ -20,3 +23,9 @@ func NewDBGetter(app *pocketbase.PocketBase) dbGetter {
 func (g dbGetter) Get() dbx.Builder {
 	return g.app.DB()
 }
+
+func (g dbGetter) RunInTransaction(fn func(tx dbx.Builder) error) error {
+	return g.app.RunInTransaction(func(txApp core.App) error {
+		return fn(txApp.DB())
+	})
+}
@@ -1,6 +1,14 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
@@ -10,24 +18,25 @@ import (
 
 type eventRepository struct {
 	db DBGetter
+	// batchSize is the amount of events saved per transaction, all events are saved in a single transaction if 0
+	batchSize int
 }
 
-func NewEventRepository(db DBGetter) eventRepository {
-	return eventRepository{db: db}
+func NewEventRepository(db DBGetter, batchSize int) eventRepository {
+	return eventRepository{db: db, batchSize: batchSize}
 }
 
-func (r eventRepository) ByBoundsAndMaxDate(bounds application.Bounds, maxDate time.Time) ([]application.Pin, error) {
-	query := r.db.Get().Select("kind", "loc").From("events").Where(dbx.And(
-		dbx.NewExp("json_extract(loc, '$.lat') >= {:south}", dbx.Params{"south": bounds.South}),
-		dbx.NewExp("json_extract(loc, '$.lat') <= {:north}", dbx.Params{"north": bounds.North}),
-		dbx.NewExp("json_extract(loc, '$.lon') >= {:west}", dbx.Params{"west": bounds.West}),
-		dbx.NewExp("json_extract(loc, '$.lon') <= {:east}", dbx.Params{"east": bounds.East}),
-		dbx.NewExp("end <= {:maxDate}", dbx.Params{"maxDate": maxDate}),
-	)).Limit(5000)
+func (r eventRepository) ByBoundsAndTimeWindow(bounds application.Bounds, window application.TimeWindow, filter application.EventFilter, limit int) ([]application.Pin, error) {
+	query := r.db.Get().Select("kind", "loc", "begin").From("events").Where(dbx.And(
+		windowExp(window),
+		filterExp(filter),
+		boundsExp(bounds),
+	)).Limit(int64(limit))
 
 	var rows []struct {
-		Kind string                 `db:"kind"`
-		Loc  types.JSONMap[float64] `db:"loc"`
+		Kind  string                 `db:"kind"`
+		Loc   types.JSONMap[float64] `db:"loc"`
+		Begin types.DateTime         `db:"begin"`
 	}
 
 	err := query.All(&rows)
@@ -42,7 +51,544 @@ func (r eventRepository) ByBoundsAndMaxDate(bounds application.Bounds, maxDate t
 			Loc:    application.EventLocation{Lat: row.Loc.Get("lat"), Lon: row.Loc.Get("lon")},
 			Amount: 1,
 		}
+		if begin := row.Begin.Time(); !begin.Before(window.From) {
+			pins[i].NextBegin = &begin
+		}
+	}
+
+	return pins, nil
+}
+
+func (r eventRepository) ClustersByBoundsAndTimeWindow(bounds application.Bounds, window application.TimeWindow, cellSize float64, filter application.EventFilter, limit int) ([]application.Pin, error) {
+	query := r.db.Get().Select(
+		"kind",
+		"COUNT(*) AS amount",
+		"AVG(json_extract(loc, '$.lat')) AS lat",
+		"AVG(json_extract(loc, '$.lon')) AS lon",
+		"MIN(CASE WHEN begin >= {:from} THEN begin END) AS next_begin",
+	).From("events").Where(dbx.And(
+		windowExp(window),
+		filterExp(filter),
+		boundsExp(bounds),
+	)).GroupBy(
+		// shift coordinates to positive values so that the integer cast floors them
+		"CAST((json_extract(loc, '$.lat') + 90) / {:cellSize} AS INTEGER)",
+		"CAST((json_extract(loc, '$.lon') + 180) / {:cellSize} AS INTEGER)",
+		"kind",
+	).Bind(dbx.Params{"cellSize": cellSize, "from": formatDate(window.From)}).Limit(int64(limit))
+
+	var rows []struct {
+		Kind      string         `db:"kind"`
+		Amount    int            `db:"amount"`
+		Lat       float64        `db:"lat"`
+		Lon       float64        `db:"lon"`
+		NextBegin types.DateTime `db:"next_begin"`
+	}
+
+	err := query.All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make([]application.Pin, len(rows))
+	for i, row := range rows {
+		pins[i] = application.Pin{
+			Kind:   application.Kind(row.Kind),
+			Loc:    application.EventLocation{Lat: row.Lat, Lon: row.Lon},
+			Amount: row.Amount,
+		}
+		if !row.NextBegin.IsZero() {
+			nextBegin := row.NextBegin.Time()
+			pins[i].NextBegin = &nextBegin
+		}
 	}
 
 	return pins, nil
 }
+
+// rtreeMaxArea is the area, in square degrees, of the bounds above which scanning events,
+// filtered by time window first, is cheaper than looking each of the events in bounds up by rowid.
+// It is about a zoom 8 map view, holding tens of thousands of events around the largest cities.
+const rtreeMaxArea = 4.0
+
+// boundsExp matches the events in the bounds.
+// Events of small bounds are looked up in the events_rtree spatial index, while large bounds fall back to a scan,
+// deciding from the bounds alone so that it does not cost a query.
+// The index stores coordinates as 32-bit floats rounded outwards, so locations are always compared exactly.
+func boundsExp(bounds application.Bounds) dbx.Expression {
+	params := dbx.Params{"south": bounds.South, "north": bounds.North, "west": bounds.West, "east": bounds.East}
+	exactExp := dbx.And(
+		dbx.NewExp("json_extract(loc, '$.lat') >= {:south}", params),
+		dbx.NewExp("json_extract(loc, '$.lat') <= {:north}", params),
+		dbx.NewExp("json_extract(loc, '$.lon') >= {:west}", params),
+		dbx.NewExp("json_extract(loc, '$.lon') <= {:east}", params),
+	)
+
+	if (bounds.North-bounds.South)*(bounds.East-bounds.West) > rtreeMaxArea {
+		return exactExp
+	}
+
+	return dbx.And(
+		dbx.NewExp("events.rowid IN (SELECT id FROM events_rtree WHERE max_lat >= {:south} AND min_lat <= {:north} AND max_lon >= {:west} AND min_lon <= {:east})", params),
+		exactExp,
+	)
+}
+
+// windowExp matches the events overlapping the window
+func windowExp(window application.TimeWindow) dbx.Expression {
+	fromExp := dbx.NewExp("end >= {:from}", dbx.Params{"from": formatDate(window.From)})
+	if window.To.IsZero() {
+		return fromExp
+	}
+	return dbx.And(
+		dbx.NewExp("begin <= {:to}", dbx.Params{"to": formatDate(window.To)}),
+		fromExp,
+	)
+}
+
+// formatDate formats a date as stored in the database, so that dates can be compared as strings
+func formatDate(date time.Time) string {
+	return date.UTC().Format(time.RFC3339)
+}
+
+// filterExp returns the condition matching the events of the filter, nil if the filter matches all events
+func filterExp(filter application.EventFilter) dbx.Expression {
+	exps := []dbx.Expression{}
+
+	if len(filter.Kinds) > 0 {
+		exps = append(exps, dbx.In("kind", kindValues(filter.Kinds)...))
+	}
+
+	if len(filter.ExcludeKinds) > 0 {
+		exps = append(exps, dbx.NotIn("kind", kindValues(filter.ExcludeKinds)...))
+	}
+
+	if len(filter.Genres) > 0 {
+		conditions := make([]string, len(filter.Genres))
+		params := dbx.Params{}
+		for i, genre := range filter.Genres {
+			param := fmt.Sprintf("genre%d", i)
+			conditions[i] = "json_each.value LIKE {:" + param + "} ESCAPE '\\'"
+			params[param] = "%" + escapeLike(strings.TrimSpace(genre)) + "%"
+		}
+		// genres which are not valid JSON never match
+		exps = append(exps, dbx.NewExp(
+			"EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(events.genres) THEN events.genres ELSE '[]' END) WHERE "+
+				strings.Join(conditions, " OR ")+")",
+			params,
+		))
+	}
+
+	// unknown prices are stored as 0 without currency
+	if filter.FreeOnly {
+		exps = append(exps, dbx.NewExp("price = 0 AND price_currency != ''"))
+	}
+
+	if filter.MaxPrice != nil {
+		exps = append(exps, dbx.NewExp(
+			"price <= {:maxPrice} AND (price > 0 OR price_currency != '')",
+			dbx.Params{"maxPrice": *filter.MaxPrice},
+		))
+	}
+
+	if len(exps) == 0 {
+		return nil
+	}
+	return dbx.And(exps...)
+}
+
+func kindValues(kinds []application.Kind) []any {
+	values := make([]any, len(kinds))
+	for i, kind := range kinds {
+		values[i] = string(kind)
+	}
+	return values
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, using \ as escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r eventRepository) ByID(id string) (application.EventRecord, error) {
+	var row eventRow
+	err := r.db.Get().Select("*").From("events").Where(dbx.HashExp{"id": id}).One(&row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return application.EventRecord{}, application.ErrEventNotFound
+	}
+	if err != nil {
+		return application.EventRecord{}, err
+	}
+
+	return application.EventRecord{ID: row.ID, Event: row.toEvent()}, nil
+}
+
+func (r eventRepository) EventsByBoundsAndTimeWindow(bounds application.Bounds, window application.TimeWindow, filter application.EventFilter, limit int) ([]application.EventRecord, error) {
+	query := r.db.Get().Select("*").From("events").Where(dbx.And(
+		windowExp(window),
+		filterExp(filter),
+		boundsExp(bounds),
+	)).OrderBy("begin", "name").Limit(int64(limit))
+
+	var rows []eventRow
+	if err := query.All(&rows); err != nil {
+		return nil, err
+	}
+
+	records := make([]application.EventRecord, len(rows))
+	for i, row := range rows {
+		records[i] = application.EventRecord{ID: row.ID, Event: row.toEvent()}
+	}
+
+	return records, nil
+}
+
+// searchMarkStart and searchMarkEnd surround matched terms in snippets,
+// control characters which cannot be altered by HTML escaping and are then replaced by <mark> tags
+const (
+	searchMarkStart = "\x02"
+	searchMarkEnd   = "\x03"
+)
+
+// Search looks for events in the events_fts full-text index, ranked with bm25 where name matches weigh the most
+func (r eventRepository) Search(query application.SearchQuery, limit int) ([]application.SearchResult, error) {
+	exps := []dbx.Expression{
+		dbx.NewExp("events_fts MATCH {:match}", dbx.Params{"match": matchExp(query.Terms())}),
+		windowExp(query.Window),
+	}
+	if query.Bounds != nil {
+		exps = append(exps, boundsExp(*query.Bounds))
+	}
+
+	q := r.db.Get().Select(
+		"events.*",
+		"bm25(events_fts, 10.0, 3.0, 1.0) AS search_rank",
+		"snippet(events_fts, -1, {:markStart}, {:markEnd}, '…', 12) AS search_snippet",
+	).From("events_fts").
+		InnerJoin("events", dbx.NewExp("events.rowid = events_fts.rowid")).
+		Where(dbx.And(exps...)).
+		Bind(dbx.Params{"markStart": searchMarkStart, "markEnd": searchMarkEnd}).
+		OrderBy("search_rank", "begin").
+		Limit(int64(limit))
+
+	var rows []struct {
+		eventRow
+		Rank    float64 `db:"search_rank"`
+		Snippet string  `db:"search_snippet"`
+	}
+	if err := q.All(&rows); err != nil {
+		return nil, err
+	}
+
+	results := make([]application.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = application.SearchResult{
+			EventRecord: application.EventRecord{ID: row.ID, Event: row.toEvent()},
+			Snippet:     highlightSnippet(row.Snippet),
+			Rank:        row.Rank,
+		}
+	}
+
+	return results, nil
+}
+
+// matchExp builds a FTS5 query matching all the terms, as prefixes so that results show up while typing.
+// Terms are quoted so that FTS5 operators in the searched text are not interpreted.
+func matchExp(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// highlightSnippet escapes a snippet to HTML and replaces its markers with <mark> tags
+func highlightSnippet(snippet string) string {
+	return strings.NewReplacer(searchMarkStart, "<mark>", searchMarkEnd, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// SaveEvents upserts valid events and reports what happened to each of them.
+// Events are saved in a single transaction, or in transactions of batchSize events if set.
+// An event failing to be saved does not prevent the others of its batch to be saved.
+func (r eventRepository) SaveEvents(ctx context.Context, events []application.Event) (application.SaveReport, error) {
+	report := application.NewSaveReport()
+
+	batchSize := r.batchSize
+	if batchSize <= 0 {
+		batchSize = max(len(events), 1)
+	}
+
+	for start := 0; start < len(events); start += batchSize {
+		end := min(start+batchSize, len(events))
+
+		results := make([]application.EventSaveResult, 0, end-start)
+		err := r.db.RunInTransaction(func(tx dbx.Builder) error {
+			for i, event := range events[start:end] {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				results = append(results, saveEvent(ctx, tx, start+i, event))
+			}
+			return nil
+		})
+		if err != nil {
+			// The whole batch has been rolled back
+			for i := start; i < end; i++ {
+				report.Add(application.EventSaveResult{Index: i, Status: application.SaveStatusFailed, Reason: err.Error()})
+			}
+			if ctx.Err() != nil {
+				return report, err
+			}
+			continue
+		}
+
+		for _, result := range results {
+			report.Add(result)
+		}
+	}
+
+	return report, nil
+}
+
+// saveEvent inserts the event, or merges it into the stored record of the same event
+func saveEvent(ctx context.Context, tx dbx.Builder, index int, event application.Event) application.EventSaveResult {
+	if err := event.Validate(); err != nil {
+		return application.EventSaveResult{Index: index, Status: application.SaveStatusSkipped, Reason: err.Error()}
+	}
+
+	failed := func(err error) application.EventSaveResult {
+		return application.EventSaveResult{Index: index, Status: application.SaveStatusFailed, Reason: err.Error()}
+	}
+
+	existing, err := findSameEvent(ctx, tx, event)
+	if err != nil {
+		return failed(fmt.Errorf("failed to find event: %w", err))
+	}
+
+	if existing == nil {
+		params, err := eventParams(event)
+		if err != nil {
+			return failed(err)
+		}
+		params["fingerprint"] = event.Fingerprint()
+
+		_, err = tx.NewQuery(`
+			INSERT INTO events (name, norm_name, fingerprint, kind, genres, begin, end, loc, place, address, price, price_currency, source, sources, img)
+			VALUES ({:name}, {:norm_name}, {:fingerprint}, {:kind}, {:genres}, {:begin}, {:end}, {:loc}, {:place}, {:address}, {:price}, {:price_currency}, {:source}, {:sources}, {:img})
+		`).Bind(params).WithContext(ctx).Execute()
+		if err != nil {
+			return failed(fmt.Errorf("failed to insert event: %w", err))
+		}
+		return application.EventSaveResult{Index: index, Status: application.SaveStatusInserted}
+	}
+
+	// Nothing is written when the merged event is the stored one, so that unchanged events do not bump the events version
+	stored := existing.toEvent()
+	merged := application.UpdateEvent(stored, event)
+	if storedEqual(merged, stored) {
+		return application.EventSaveResult{Index: index, Status: application.SaveStatusUnchanged}
+	}
+
+	// The fingerprint of the stored record is kept, so that the event identity is stable
+	params, err := eventParams(merged)
+	if err != nil {
+		return failed(err)
+	}
+	params["id"] = existing.ID
+
+	_, err = tx.NewQuery(`
+		UPDATE events SET
+			name = {:name},
+			norm_name = {:norm_name},
+			kind = {:kind},
+			genres = {:genres},
+			begin = {:begin},
+			end = {:end},
+			loc = {:loc},
+			place = {:place},
+			address = {:address},
+			price = {:price},
+			price_currency = {:price_currency},
+			source = {:source},
+			sources = {:sources},
+			img = {:img}
+		WHERE id = {:id}
+	`).Bind(params).WithContext(ctx).Execute()
+	if err != nil {
+		return failed(fmt.Errorf("failed to update event: %w", err))
+	}
+	return application.EventSaveResult{Index: index, Status: application.SaveStatusUpdated}
+}
+
+// findSameEvent returns the stored record of the event, nil if there is none.
+// Candidates share the fingerprint of the event, or its normalized title and a close begin time.
+func findSameEvent(ctx context.Context, tx dbx.Builder, event application.Event) (*eventRow, error) {
+	fingerprint := event.Fingerprint()
+
+	var candidates []eventRow
+	err := tx.Select("*").From("events").Where(dbx.Or(
+		dbx.HashExp{"fingerprint": fingerprint},
+		dbx.And(
+			dbx.HashExp{"norm_name": application.NormalizeTitle(event.Name)},
+			dbx.Between("begin",
+				formatDate(event.Begin.Add(-application.SameEventMaxBeginDelta)),
+				formatDate(event.Begin.Add(application.SameEventMaxBeginDelta)),
+			),
+		),
+	)).WithContext(ctx).All(&candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Fingerprint == fingerprint || candidate.toEvent().IsSameEvent(event) {
+			return &candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// storedEqual tells whether both events are stored the same, comparing the columns written by eventParams
+func storedEqual(a, b application.Event) bool {
+	return a.Name == b.Name &&
+		a.Kind == b.Kind &&
+		slices.Equal(a.Genres, b.Genres) &&
+		formatDate(a.Begin) == formatDate(b.Begin) &&
+		formatDate(a.End) == formatDate(b.End) &&
+		a.Loc == b.Loc &&
+		a.Place == b.Place &&
+		a.Address == b.Address &&
+		storedPrice(a) == storedPrice(b) &&
+		storedCurrency(a) == storedCurrency(b) &&
+		a.Source == b.Source &&
+		slices.Equal(a.AllSources(), b.AllSources()) &&
+		a.Img == b.Img
+}
+
+// storedPrice is the price column of the event, 0 when unknown
+func storedPrice(event application.Event) float64 {
+	if event.Price == nil {
+		return 0
+	}
+	return *event.Price
+}
+
+// storedCurrency is the price_currency column of the event, empty when unknown
+func storedCurrency(event application.Event) string {
+	if event.PriceCurrency == nil {
+		return ""
+	}
+	return *event.PriceCurrency
+}
+
+// eventParams returns the stored columns of the event, dates being stored in UTC
+func eventParams(event application.Event) (dbx.Params, error) {
+	genresJSON, err := json.Marshal(event.Genres)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal genres: %w", err)
+	}
+
+	locJSON, err := json.Marshal(event.Loc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal loc: %w", err)
+	}
+
+	sourcesJSON, err := json.Marshal(event.AllSources())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	return dbx.Params{
+		"name":           event.Name,
+		"norm_name":      application.NormalizeTitle(event.Name),
+		"kind":           event.Kind,
+		"genres":         genresJSON,
+		"begin":          formatDate(event.Begin),
+		"end":            formatDate(event.End),
+		"loc":            locJSON,
+		"place":          event.Place,
+		"address":        event.Address,
+		"price":          storedPrice(event),
+		"price_currency": storedCurrency(event),
+		"source":         event.Source,
+		"sources":        sourcesJSON,
+		"img":            event.Img,
+	}, nil
+}
+
+// eventRow is a stored event
+type eventRow struct {
+	ID            string                 `db:"id"`
+	Fingerprint   string                 `db:"fingerprint"`
+	Name          string                 `db:"name"`
+	Kind          string                 `db:"kind"`
+	Genres        types.JSONRaw          `db:"genres"`
+	Begin         types.DateTime         `db:"begin"`
+	End           types.DateTime         `db:"end"`
+	Loc           types.JSONMap[float64] `db:"loc"`
+	Place         string                 `db:"place"`
+	Address       string                 `db:"address"`
+	Price         float64                `db:"price"`
+	PriceCurrency string                 `db:"price_currency"`
+	Source        string                 `db:"source"`
+	Sources       types.JSONRaw          `db:"sources"`
+	Img           string                 `db:"img"`
+}
+
+func (r eventRow) toEvent() application.Event {
+	event := application.Event{
+		Name:    r.Name,
+		Kind:    application.Kind(r.Kind),
+		Begin:   r.Begin.Time(),
+		End:     r.End.Time(),
+		Loc:     application.EventLocation{Lat: r.Loc.Get("lat"), Lon: r.Loc.Get("lon")},
+		Place:   r.Place,
+		Address: r.Address,
+		Source:  r.Source,
+		Img:     r.Img,
+	}
+
+	// unknown prices are stored as 0 without currency
+	if r.Price != 0 || r.PriceCurrency != "" {
+		event.Price = &r.Price
+	}
+	if r.PriceCurrency != "" {
+		event.PriceCurrency = &r.PriceCurrency
+	}
+
+	// malformed lists are ignored, they are rewritten on update
+	_ = json.Unmarshal(r.Genres, &event.Genres)
+	_ = json.Unmarshal(r.Sources, &event.Sources)
+
+	return event
+}
+
+// RebuildIndexes refills events_rtree and events_fts from events. Both match events by their implicit rowid,
+// which a VACUUM may renumber, so they are rebuilt on startup rather than trusted to still match.
+func (r eventRepository) RebuildIndexes(ctx context.Context) error {
+	queries := []string{
+		"DELETE FROM events_rtree",
+		`INSERT INTO events_rtree
+			SELECT rowid,
+				json_extract(loc, '$.lat'), json_extract(loc, '$.lat'),
+				json_extract(loc, '$.lon'), json_extract(loc, '$.lon')
+			FROM events`,
+		"INSERT INTO events_fts(events_fts) VALUES ('rebuild')",
+	}
+
+	return r.db.RunInTransaction(func(tx dbx.Builder) error {
+		for _, query := range queries {
+			if _, err := tx.NewQuery(query).WithContext(ctx).Execute(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r eventRepository) Version() (int64, error) {
+	var version int64
+	err := r.db.Get().Select("version").From("events_version").Row(&version)
+	return version, err
+}
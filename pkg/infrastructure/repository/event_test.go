@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+
+	_ "github.com/leorolland/sortir.in/migrations"
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/repository"
+)
+
+const benchmarkEvents = 1_000_000
+
+// TestRebuildIndexes empties the spatial and full-text indexes, as if a VACUUM had renumbered the events
+func TestRebuildIndexes(t *testing.T) {
+	app := pocketbase.NewWithConfig(pocketbase.Config{DefaultDataDir: t.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	eventRepository := repository.NewEventRepository(repository.NewDBGetter(app), 0)
+	begin := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	event := application.Event{Name: "Concert d'orgue", Kind: application.KindConcert, Begin: begin, End: begin.Add(2 * time.Hour), Loc: application.EventLocation{Lat: 48.86, Lon: 2.34}}
+	if _, err := eventRepository.SaveEvents(context.Background(), []application.Event{event}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"DELETE FROM events_rtree", "INSERT INTO events_fts(events_fts) VALUES ('delete-all')"} {
+		if _, err := app.DB().NewQuery(query).Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := eventRepository.RebuildIndexes(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	window := application.TimeWindow{From: time.Now(), To: begin.Add(24 * time.Hour)}
+	paris := application.Bounds{North: 48.91, South: 48.81, East: 2.42, West: 2.25}
+	pins, err := eventRepository.ByBoundsAndTimeWindow(paris, window, application.EventFilter{}, 10)
+	if err != nil || len(pins) != 1 {
+		t.Errorf("Expected the event to be found in the bounds, got %d pins, %v", len(pins), err)
+	}
+	results, err := eventRepository.Search(application.SearchQuery{Text: "orgue", Window: window}, 10)
+	if err != nil || len(results) != 1 {
+		t.Errorf("Expected the event to be found by its name, got %d results, %v", len(results), err)
+	}
+}
+
+// BenchmarkPins measures pin queries on a database of a million events spread over France and the next 60 days
+func BenchmarkPins(b *testing.B) {
+	if testing.Short() {
+		b.Skip("populating the database takes a while")
+	}
+
+	app := pocketbase.NewWithConfig(pocketbase.Config{DefaultDataDir: b.TempDir()})
+	if err := app.Bootstrap(); err != nil {
+		b.Fatal(err)
+	}
+	if err := app.RunAllMigrations(); err != nil {
+		b.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	populateEvents(b, app, now)
+
+	eventRepository := repository.NewEventRepository(repository.NewDBGetter(app), 0)
+	pinsService := application.NewPins(eventRepository)
+
+	week := application.TimeWindow{From: now, To: now.Add(7 * 24 * time.Hour)}
+	paris := application.Bounds{North: 48.91, South: 48.81, East: 2.42, West: 2.25}
+	region := application.Bounds{North: 49.5, South: 48.3, East: 4, West: 0.5}
+	france := application.Bounds{North: 51.1, South: 42.3, East: 8.2, West: -4.8}
+
+	b.Run("city pins", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := pinsService.GetClusters(paris, week, 14, application.EventFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("city clusters", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := pinsService.GetClusters(paris, week, 11, application.EventFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("region clusters", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := pinsService.GetClusters(region, week, 8, application.EventFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("country clusters", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := pinsService.GetClusters(france, week, 5, application.EventFilter{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// populateEvents inserts benchmarkEvents deterministic events in a single statement, indexes being maintained by triggers
+func populateEvents(b *testing.B, app *pocketbase.PocketBase, now time.Time) {
+	b.Helper()
+
+	_, err := app.DB().NewQuery(`
+		WITH RECURSIVE seq(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM seq WHERE i < {:amount} - 1)
+		INSERT INTO events (id, fingerprint, name, norm_name, kind, begin, end, loc)
+		SELECT
+			printf('%015d', i),
+			printf('%032x', i),
+			'Event ' || i,
+			'event ' || i,
+			'concert',
+			strftime('%Y-%m-%dT%H:%M:%SZ', {:now}, '+' || (i % 1440) || ' hours'),
+			strftime('%Y-%m-%dT%H:%M:%SZ', {:now}, '+' || (i % 1440 + 2) || ' hours'),
+			json_object(
+				'lat', 42.3 + ((i * 7919) % 1000003) * 8.8 / 1000003,
+				'lon', -4.8 + ((i * 104729) % 999983) * 13.0 / 999983
+			)
+		FROM seq
+	`).Bind(dbx.Params{"amount": benchmarkEvents, "now": now.Format(time.RFC3339)}).Execute()
+	if err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type locationRepository struct {
+	db DBGetter
+}
+
+func NewLocationRepository(db DBGetter) locationRepository {
+	return locationRepository{db: db}
+}
+
+func (r locationRepository) EnabledLocations(ctx context.Context) ([]application.CollectLocation, error) {
+	var rows []struct {
+		Name   string  `db:"name"`
+		Lat    float64 `db:"lat"`
+		Lon    float64 `db:"lon"`
+		Radius float64 `db:"radius"`
+	}
+
+	// never collected locations have an empty date, sorted first
+	err := r.db.Get().Select("name", "lat", "lon", "radius").From("locations").
+		Where(dbx.HashExp{"enabled": true}).
+		OrderBy("priority DESC", "last_collected_at ASC", "name ASC").
+		WithContext(ctx).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]application.CollectLocation, len(rows))
+	for i, row := range rows {
+		locations[i] = application.CollectLocation{City: row.Name, Lat: row.Lat, Lon: row.Lon, Radius: row.Radius}
+	}
+	return locations, nil
+}
+
+func (r locationRepository) MarkCollected(ctx context.Context, city string, at time.Time) error {
+	collectedAt, err := types.ParseDateTime(at)
+	if err != nil {
+		return err
+	}
+
+	// dates are written in the format of PocketBase, to be sorted along the ones set from the admin UI
+	result, err := r.db.Get().Update("locations",
+		dbx.Params{"last_collected_at": collectedAt.String(), "updated": types.NowDateTime().String()},
+		dbx.HashExp{"name": city},
+	).WithContext(ctx).Execute()
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return application.ErrLocationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// bindCollectionCrons registers a cron job for each collection schedule of the file at schedulesPath,
+// running the collectors as configured in collectorsConfig, for cities among locations.
+// Collected events are saved directly with the event saver of the app store,
+// and each run collects the enabled locations of the location repository, the stalest first.
+func bindCollectionCrons(app *pocketbase.PocketBase, schedulesPath string, collectorsConfig collector.Config, locations []application.CollectLocation) error {
+	schedules, err := collector.LoadSchedules(schedulesPath, collectorsConfig, locations)
+	if err != nil {
+		return err
+	}
+
+	eventSaver, ok := app.Store().Get("eventSaver").(application.EventSaver)
+	if !ok {
+		return fmt.Errorf("event saver not found")
+	}
+
+	locationRepository, ok := app.Store().Get("locationRepository").(application.LocationRepository)
+	if !ok {
+		return fmt.Errorf("location repository not found")
+	}
+
+	collectionRunRepository, ok := app.Store().Get("collectionRunRepository").(application.CollectionRunRepository)
+	if !ok {
+		return fmt.Errorf("collection run repository not found")
+	}
+
+	cursorRepository, ok := app.Store().Get("cursorRepository").(application.CursorRepository)
+	if !ok {
+		return fmt.Errorf("cursor repository not found")
+	}
+
+	// Cancel the running collections when the app terminates
+	ctx, cancel := context.WithCancel(context.Background())
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		cancel()
+		return e.Next()
+	})
+
+	for _, schedule := range schedules {
+		scheduleCollector, err := schedule.Collector(collectorsConfig)
+		if err != nil {
+			return fmt.Errorf("schedule %s: %w", schedule.Name, err)
+		}
+
+		job := &collectionJob{
+			app:                app,
+			schedule:           schedule,
+			populator:          application.NewIncrementalPopulator(scheduleCollector, eventSaver, cursorRepository, schedule.Full),
+			locationRepository: locationRepository,
+			runRepository:      collectionRunRepository,
+		}
+
+		if err := app.Cron().Add("collect_"+schedule.Name, schedule.Cron, func() { job.run(ctx) }); err != nil {
+			return fmt.Errorf("schedule %s: %w", schedule.Name, err)
+		}
+
+		app.Logger().Info("Scheduled collection", "schedule", schedule.Name, "cron", schedule.Cron, "collectors", schedule.Collectors)
+	}
+
+	return nil
+}
+
+type collectionJob struct {
+	app                *pocketbase.PocketBase
+	schedule           collector.Schedule
+	populator          application.Populator
+	locationRepository application.LocationRepository
+	runRepository      application.CollectionRunRepository
+	// running prevents a run from starting while the previous one is still in progress
+	running sync.Mutex
+}
+
+func (j *collectionJob) run(ctx context.Context) {
+	if !j.running.TryLock() {
+		j.app.Logger().Warn("Skipping collection, previous run still in progress", "schedule", j.schedule.Name)
+		return
+	}
+	defer j.running.Unlock()
+
+	if j.schedule.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(j.schedule.Timeout))
+		defer cancel()
+	}
+
+	startedAt := time.Now()
+	locations, err := j.locationRepository.EnabledLocations(ctx)
+	if err != nil {
+		j.app.Logger().Error("Failed to get locations", "schedule", j.schedule.Name, "error", err)
+		return
+	}
+
+	collectCtx := collector.WithCacheStats(ctx)
+	if j.schedule.Budget > 0 {
+		collectCtx = collector.WithRequestBudget(collectCtx, j.schedule.Budget)
+	}
+
+	iterator := application.NewTrackedLocationsIterator(j.schedule.Locations(locations), j.locationRepository)
+	run, err := j.populator.PopulateLocations(collectCtx, iterator, 0)
+	run.Name = j.schedule.Name
+	run.Cache = collector.CacheStats(collectCtx)
+
+	// The run is saved even when interrupted, so that it shows in the history
+	if saveErr := j.runRepository.SaveRun(context.WithoutCancel(ctx), run); saveErr != nil {
+		j.app.Logger().Error("Failed to save collection run", "schedule", j.schedule.Name, "error", saveErr)
+	}
+
+	if err != nil {
+		j.app.Logger().Error("Collection interrupted", "schedule", j.schedule.Name, "locations", run.Succeeded(), "duration", time.Since(startedAt), "error", err)
+		return
+	}
+
+	j.app.Logger().Info("Collection done", "schedule", j.schedule.Name, "locations", run.Succeeded(), "duration", time.Since(startedAt), "cache", run.Cache)
+}
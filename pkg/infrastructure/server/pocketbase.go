@@ -1,9 +1,14 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/collector"
 	"github.com/leorolland/sortir.in/pkg/infrastructure/repository"
 	"github.com/leorolland/sortir.in/pkg/infrastructure/server/requests"
 	"github.com/leorolland/sortir.in/ui"
@@ -13,23 +18,96 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// IngestAPIKeyEnv is the environment variable holding the API key allowed to ingest events
+const IngestAPIKeyEnv = "SORTIR_INGEST_API_KEY"
+
+// SaveBatchSizeEnv is the environment variable holding the amount of events saved per transaction,
+// a whole ingested batch is saved in a single transaction if not set
+const SaveBatchSizeEnv = "SORTIR_SAVE_BATCH_SIZE"
+
 func RegisterApp(app *pocketbase.PocketBase) {
+	var collectionSchedulesPath string
+	app.RootCmd.PersistentFlags().StringVar(&collectionSchedulesPath, "collection-schedules", "", "JSON file describing the scheduled event collections, no collection is scheduled if empty")
+	var collectorsConfigPath string
+	app.RootCmd.PersistentFlags().StringVar(&collectorsConfigPath, "collectors-config", "", "YAML or JSON file configuring the collectors, all collectors run with their defaults if empty")
+
 	initServices(app)
 	bindRoutes(app)
 	bindCrons(app)
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		// The spatial and full-text indexes of events may point to other events after a VACUUM
+		startedAt := time.Now()
+		if err := repository.NewEventRepository(repository.NewDBGetter(app), 0).RebuildIndexes(context.Background()); err != nil {
+			return fmt.Errorf("failed to rebuild the events indexes: %w", err)
+		}
+		app.Logger().Info("Rebuilt the events indexes", "duration", time.Since(startedAt))
+
+		locationRepository, ok := app.Store().Get("locationRepository").(application.LocationRepository)
+		if !ok {
+			return fmt.Errorf("location repository not found")
+		}
+		locations, err := locationRepository.EnabledLocations(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get locations: %w", err)
+		}
+
+		collectorsConfig := collector.DefaultConfig()
+		if collectorsConfigPath != "" {
+			collectorsConfig, err = collector.LoadConfig(collectorsConfigPath, locations)
+			if err != nil {
+				return fmt.Errorf("failed to load collectors configuration: %w", err)
+			}
+		}
+
+		if collectionSchedulesPath != "" {
+			if err := bindCollectionCrons(app, collectionSchedulesPath, collectorsConfig, locations); err != nil {
+				return fmt.Errorf("failed to schedule collections: %w", err)
+			}
+		}
+		return se.Next()
+	})
 }
 
 func initServices(app *pocketbase.PocketBase) {
+	batchSize := 0
+	if value := os.Getenv(SaveBatchSizeEnv); value != "" {
+		var err error
+		batchSize, err = strconv.Atoi(value)
+		if err != nil {
+			app.Logger().Warn("Invalid save batch size, saving batches in a single transaction", "env", SaveBatchSizeEnv, "error", err)
+		}
+	}
+
 	dbGetter := repository.NewDBGetter(app)
-	eventRepository := repository.NewEventRepository(dbGetter)
-	app.Store().Set("pinsService", application.NewPins(eventRepository))
+	eventRepository := repository.NewEventRepository(dbGetter, batchSize)
+	pinsService := application.NewPins(eventRepository)
+	app.Store().Set("pinsService", pinsService)
+	app.Store().Set("tilesService", application.NewTiles(pinsService, eventRepository))
+	app.Store().Set("eventsService", application.NewEvents(eventRepository))
+	app.Store().Set("eventSaver", eventRepository)
+	app.Store().Set("locationRepository", repository.NewLocationRepository(dbGetter))
+	app.Store().Set("collectionRunRepository", repository.NewCollectionRunRepository(dbGetter))
+	app.Store().Set("cursorRepository", repository.NewCursorRepository(dbGetter))
 }
 
 func bindRoutes(app *pocketbase.PocketBase) {
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
 		se.Router.GET("/{path...}", apis.Static(ui.BuildDirFS, true)).Bind(apis.Gzip())
-		se.Router.PUT("/api/events", requests.PutEvents)
+		se.Router.GET("/api/events", requests.GetEvents)
+		se.Router.GET("/api/events.geojson", requests.GetEventsGeoJSON)
+		se.Router.GET("/api/events/{id}", requests.GetEvent)
+		se.Router.GET("/api/search", requests.Search)
+		se.Router.GET("/api/calendar.ics", requests.GetCalendar)
+		se.Router.PUT("/api/events", requests.PutEvents).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
+		se.Router.POST("/api/collection_runs", requests.PostCollectionRun).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
+		se.Router.GET("/api/locations", requests.GetLocations).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
+		se.Router.PUT("/api/locations/{name}/collected", requests.PutLocationCollected).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
+		se.Router.GET("/api/locations/{name}/cursors", requests.GetCursors).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
+		se.Router.PUT("/api/locations/{name}/cursors", requests.PutCursors).BindFunc(requests.RequireIngestAuth(os.Getenv(IngestAPIKeyEnv)))
 		se.Router.GET("/api/pins", requests.GetPins)
+		se.Router.GET("/api/pins.geojson", requests.GetPinsGeoJSON)
+		se.Router.GET("/api/tiles/{z}/{x}/{y}", requests.GetTile)
 		return se.Next()
 	})
 }
@@ -37,7 +115,7 @@ func bindRoutes(app *pocketbase.PocketBase) {
 func bindCrons(app *pocketbase.PocketBase) {
 	app.Cron().MustAdd("delete_expired_events_cron", "* * * * *", func() {
 		_, err := app.DB().Delete("events",
-			dbx.NewExp("end < {:now}", dbx.Params{"now": time.Now().Format("2006-01-02T15:04:05Z")}),
+			dbx.NewExp("end < {:now}", dbx.Params{"now": time.Now().UTC().Format(time.RFC3339)}),
 		).Execute()
 		if err != nil {
 			app.Logger().Error("failed to delete expired events", "error", err)
@@ -0,0 +1,27 @@
+package requests
+
+import (
+	"crypto/subtle"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// APIKeyHeader is the header carrying the API key of ingestion clients
+const APIKeyHeader = "X-API-Key"
+
+// RequireIngestAuth only lets through requests authenticated as a superuser,
+// or carrying the ingestion API key. API key authentication is disabled when apiKey is empty.
+func RequireIngestAuth(apiKey string) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		if e.HasSuperuserAuth() {
+			return e.Next()
+		}
+
+		requestKey := e.Request.Header.Get(APIKeyHeader)
+		if apiKey != "" && subtle.ConstantTimeCompare([]byte(requestKey), []byte(apiKey)) == 1 {
+			return e.Next()
+		}
+
+		return e.UnauthorizedError("The request requires an API key or a superuser authorization token.", nil)
+	}
+}
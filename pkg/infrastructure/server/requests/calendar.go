@@ -0,0 +1,135 @@
+package requests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/ical"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const calendarProdID = "-//sortir.in//sortir.in//FR"
+
+// calendarRefreshInterval is how often calendar apps are asked to refresh feeds
+const calendarRefreshInterval = 6 * time.Hour
+
+// getEventCalendar returns a calendar holding a single event, for calendar apps to import it
+func getEventCalendar(e *core.RequestEvent, id string) error {
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	record, err := eventsService.GetEvent(id)
+	if errors.Is(err, application.ErrEventNotFound) {
+		return e.Error(http.StatusNotFound, "event not found", nil)
+	}
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get event: %v", err), nil)
+	}
+
+	e.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ics"`, record.ID))
+	return calendar(e, ical.Calendar{
+		ProdID: calendarProdID,
+		Stamp:  time.Now(),
+		Events: []ical.Event{newCalendarEvent(record)},
+	})
+}
+
+// GetCalendar returns a calendar feed of the events in bounds (west,south,east,north) which have not ended,
+// accepting the filters of GetPins and an optional to date
+func GetCalendar(e *core.RequestEvent) error {
+	queryParams := e.Request.URL.Query()
+
+	bounds, err := getOptionalBoundsFromQueryParam(queryParams, "bounds")
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid bounds: %v", err), nil)
+	}
+	if bounds == nil {
+		return e.Error(http.StatusBadRequest, "bounds are required", nil)
+	}
+
+	window, err := getOpenTimeWindowFromQueryParams(queryParams)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	filter, err := getFilterFromQueryParams(queryParams)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid filter: %v", err), nil)
+	}
+
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	records, err := eventsService.GetEvents(*bounds, window, filter)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get events: %v", err), nil)
+	}
+
+	events := make([]ical.Event, len(records))
+	for i, record := range records {
+		events[i] = newCalendarEvent(record)
+	}
+
+	return calendar(e, ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            "sortir.in",
+		RefreshInterval: calendarRefreshInterval,
+		Stamp:           time.Now(),
+		Events:          events,
+	})
+}
+
+func newCalendarEvent(record application.EventRecord) ical.Event {
+	var location []string
+	for _, part := range []string{record.Place, record.Address} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+
+	var description []string
+	if record.Price != nil {
+		price := strconv.FormatFloat(*record.Price, 'f', -1, 64)
+		switch {
+		case record.PriceCurrency == nil:
+			description = append(description, "Prix : "+price)
+		case *record.Price == 0:
+			description = append(description, "Gratuit")
+		default:
+			description = append(description, "Prix : "+price+" "+*record.PriceCurrency)
+		}
+	}
+	if sources := record.AllSources(); len(sources) > 1 {
+		description = append(description, "Sources : "+strings.Join(sources, " "))
+	}
+
+	return ical.Event{
+		UID:         record.ID + "@sortir.in",
+		Summary:     record.Name,
+		Start:       record.Begin,
+		End:         record.End,
+		Location:    strings.Join(location, ", "),
+		Geo:         &ical.Geo{Lat: record.Loc.Lat, Lon: record.Loc.Lon},
+		URL:         record.Source,
+		Categories:  append([]string{string(record.Kind)}, record.Genres...),
+		Description: strings.Join(description, "\n"),
+	}
+}
+
+func calendar(e *core.RequestEvent, calendar ical.Calendar) error {
+	var b bytes.Buffer
+	if err := ical.Encode(&b, calendar); err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to encode calendar: %v", err), nil)
+	}
+	return e.Blob(http.StatusOK, ical.ContentType, b.Bytes())
+}
@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// PostCollectionRun saves the outcome of a populate run made outside of the server
+func PostCollectionRun(e *core.RequestEvent) error {
+	var run application.CollectionRun
+	if err := json.NewDecoder(e.Request.Body).Decode(&run); err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid collection run: %v", err), nil)
+	}
+
+	collectionRunRepository, ok := e.App.Store().Get("collectionRunRepository").(application.CollectionRunRepository)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "collection run repository not found", nil)
+	}
+
+	if err := collectionRunRepository.SaveRun(e.Request.Context(), run); err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to save collection run: %v", err), nil)
+	}
+
+	return e.NoContent(http.StatusCreated)
+}
@@ -0,0 +1,45 @@
+package requests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// GetCursors returns the cursors of the sources collected for the location named name, by source
+func GetCursors(e *core.RequestEvent) error {
+	cursorRepository, ok := e.App.Store().Get("cursorRepository").(application.CursorRepository)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "cursor repository not found", nil)
+	}
+
+	cursors, err := cursorRepository.Cursors(e.Request.Context(), e.Request.PathValue("name"))
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get cursors: %v", err), nil)
+	}
+
+	return e.JSON(http.StatusOK, cursors)
+}
+
+// PutCursors stores the cursors of the body, by source, for the location named name.
+// The cursors of the sources missing from the body are kept.
+func PutCursors(e *core.RequestEvent) error {
+	var cursors map[string]string
+	if err := json.NewDecoder(e.Request.Body).Decode(&cursors); err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err), nil)
+	}
+
+	cursorRepository, ok := e.App.Store().Get("cursorRepository").(application.CursorRepository)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "cursor repository not found", nil)
+	}
+
+	if err := cursorRepository.SaveCursors(e.Request.Context(), e.Request.PathValue("name"), cursors); err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to save cursors: %v", err), nil)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
@@ -2,11 +2,15 @@ package requests
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
-	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 )
 
@@ -16,62 +20,158 @@ func PutEvents(e *core.RequestEvent) error {
 		return e.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	for _, event := range events {
-		genresJSON, err := json.Marshal(event.Genres)
-		if err != nil {
-			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to marshal genres: " + err.Error()})
-		}
-
-		locJSON, err := json.Marshal(event.Loc)
-		if err != nil {
-			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to marshal loc: " + err.Error()})
-		}
-
-		if !event.IsValid() {
-			continue
-		}
-
-		priceFloat := 0.0
-		if event.Price != nil {
-			priceFloat = *event.Price
-		}
-
-		currencyString := ""
-		if event.PriceCurrency != nil {
-			currencyString = *event.PriceCurrency
-		}
-
-		_, err = e.App.DB().NewQuery(`
-			INSERT INTO events (name, kind, genres, begin, end, loc, place, address, price, price_currency, source, img)
-			VALUES ({:name}, {:kind}, {:genres}, {:begin}, {:end}, {:loc}, {:place}, {:address}, {:price}, {:price_currency}, {:source}, {:img})
-			ON CONFLICT (name, begin, end) DO UPDATE SET
-				kind = {:kind},
-				genres = {:genres},
-				loc = {:loc},
-				place = {:place},
-				address = {:address},
-				price = {:price},
-				price_currency = {:price_currency},
-				source = {:source},
-				img = {:img}
-		`).Bind(dbx.Params{
-			"name":           event.Name,
-			"kind":           event.Kind,
-			"genres":         genresJSON,
-			"begin":          event.Begin.Format(time.RFC3339),
-			"end":            event.End.Format(time.RFC3339),
-			"loc":            locJSON,
-			"place":          event.Place,
-			"address":        event.Address,
-			"price":          priceFloat,
-			"price_currency": currencyString,
-			"source":         event.Source,
-			"img":            event.Img,
-		}).Execute()
-		if err != nil {
-			return e.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update event: " + err.Error()})
-		}
-	}
-
-	return e.JSON(http.StatusOK, map[string]string{"message": "Events batch updated"})
+	eventSaver, ok := e.App.Store().Get("eventSaver").(application.EventSaver)
+	if !ok {
+		return e.JSON(http.StatusInternalServerError, map[string]string{"error": "event saver not found"})
+	}
+
+	report, err := eventSaver.SaveEvents(e.Request.Context(), events)
+	if err != nil {
+		return e.JSON(http.StatusInternalServerError, map[string]any{"error": err.Error(), "report": report})
+	}
+
+	return e.JSON(http.StatusOK, report)
+}
+
+// eventResponse is the public representation of an event
+type eventResponse struct {
+	ID            string                    `json:"id"`
+	Name          string                    `json:"name"`
+	Kind          application.Kind          `json:"kind"`
+	Genres        []string                  `json:"genres"`
+	Begin         time.Time                 `json:"begin"`
+	End           time.Time                 `json:"end"`
+	Loc           application.EventLocation `json:"loc"`
+	Place         string                    `json:"place"`
+	Address       string                    `json:"address"`
+	Price         *float64                  `json:"price,omitempty"`
+	PriceCurrency *string                   `json:"price_currency,omitempty"`
+	Source        string                    `json:"source"`
+	Sources       []string                  `json:"sources"`
+	Img           string                    `json:"img"`
+}
+
+func newEventResponse(record application.EventRecord) eventResponse {
+	genres := record.Genres
+	if genres == nil {
+		genres = []string{}
+	}
+
+	return eventResponse{
+		ID:            record.ID,
+		Name:          record.Name,
+		Kind:          record.Kind,
+		Genres:        genres,
+		Begin:         record.Begin.UTC(),
+		End:           record.End.UTC(),
+		Loc:           record.Loc,
+		Place:         record.Place,
+		Address:       record.Address,
+		Price:         record.Price,
+		PriceCurrency: record.PriceCurrency,
+		Source:        record.Source,
+		Sources:       record.AllSources(),
+		Img:           record.Img,
+	}
+}
+
+// GetEvent returns an event, or an iCalendar file of the event if its identifier is suffixed with .ics
+func GetEvent(e *core.RequestEvent) error {
+	if id, ok := strings.CutSuffix(e.Request.PathValue("id"), ".ics"); ok {
+		return getEventCalendar(e, id)
+	}
+
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	record, err := eventsService.GetEvent(e.Request.PathValue("id"))
+	if errors.Is(err, application.ErrEventNotFound) {
+		return e.Error(http.StatusNotFound, "event not found", nil)
+	}
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get event: %v", err), nil)
+	}
+
+	return e.JSON(http.StatusOK, newEventResponse(record))
+}
+
+// GetEvents returns the events at a location, as pointed by a pin
+func GetEvents(e *core.RequestEvent) error {
+	loc, err := getLocationFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid location: %v", err), nil)
+	}
+
+	window, err := getTimeWindowFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	records, err := eventsService.GetEventsAt(loc, window)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get events: %v", err), nil)
+	}
+
+	events := make([]eventResponse, len(records))
+	for i, record := range records {
+		events[i] = newEventResponse(record)
+	}
+
+	return e.JSON(http.StatusOK, events)
+}
+
+// GetEventsGeoJSON returns the events in bounds as a GeoJSON feature collection, accepting the params of GetPins
+func GetEventsGeoJSON(e *core.RequestEvent) error {
+	bounds, err := getBoundsFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid bounds: %v", err), nil)
+	}
+
+	window, err := getTimeWindowFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	filter, err := getFilterFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid filter: %v", err), nil)
+	}
+
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	records, err := eventsService.GetEvents(bounds, window, filter)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get events: %v", err), nil)
+	}
+
+	features := make([]geoJSONFeature, len(records))
+	for i, record := range records {
+		features[i] = newGeoJSONPoint(record.ID, record.Loc, newEventResponse(record))
+	}
+
+	return geoJSON(e, features)
+}
+
+func getLocationFromQueryParams(queryParams url.Values) (application.EventLocation, error) {
+	lat, err := strconv.ParseFloat(queryParams.Get("lat"), 64)
+	if err != nil {
+		return application.EventLocation{}, fmt.Errorf("invalid lat: %w", err)
+	}
+
+	lon, err := strconv.ParseFloat(queryParams.Get("lon"), 64)
+	if err != nil {
+		return application.EventLocation{}, fmt.Errorf("invalid lon: %w", err)
+	}
+
+	return application.EventLocation{Lat: lat, Lon: lon}, nil
 }
@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"net/http"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// geoJSONFeature is a GeoJSON (RFC 7946) point feature
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties any             `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type string `json:"type"`
+	// Coordinates are ordered as longitude, latitude
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func newGeoJSONPoint(id string, loc application.EventLocation, properties any) geoJSONFeature {
+	return geoJSONFeature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float64{loc.Lon, loc.Lat}},
+		Properties: properties,
+	}
+}
+
+// geoJSON responds with a feature collection of the features
+func geoJSON(e *core.RequestEvent, features []geoJSONFeature) error {
+	e.Response.Header().Set("Content-Type", "application/geo+json")
+	return e.JSON(http.StatusOK, geoJSONFeatureCollection{Type: "FeatureCollection", Features: features})
+}
@@ -0,0 +1,71 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// locationResponse is the representation of a location to collect
+type locationResponse struct {
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+	Radius float64 `json:"radius"`
+}
+
+// GetLocations returns the enabled locations to collect, the stalest first
+func GetLocations(e *core.RequestEvent) error {
+	locationRepository, ok := e.App.Store().Get("locationRepository").(application.LocationRepository)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "location repository not found", nil)
+	}
+
+	locations, err := locationRepository.EnabledLocations(e.Request.Context())
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get locations: %v", err), nil)
+	}
+
+	response := make([]locationResponse, len(locations))
+	for i, location := range locations {
+		response[i] = locationResponse{Name: location.City, Lat: location.Lat, Lon: location.Lon, Radius: location.Radius}
+	}
+
+	return e.JSON(http.StatusOK, response)
+}
+
+// PutLocationCollected records when the location named name was collected,
+// at the date of the optional body {"at": "<RFC3339 date>"}, now by default
+func PutLocationCollected(e *core.RequestEvent) error {
+	var body struct {
+		At *time.Time `json:"at"`
+	}
+	if err := json.NewDecoder(e.Request.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err), nil)
+	}
+	at := time.Now()
+	if body.At != nil {
+		at = *body.At
+	}
+
+	locationRepository, ok := e.App.Store().Get("locationRepository").(application.LocationRepository)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "location repository not found", nil)
+	}
+
+	err := locationRepository.MarkCollected(e.Request.Context(), e.Request.PathValue("name"), at)
+	if errors.Is(err, application.ErrLocationNotFound) {
+		return e.Error(http.StatusNotFound, "location not found", nil)
+	}
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to mark location as collected: %v", err), nil)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
@@ -5,34 +5,87 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/leorolland/sortir.in/pkg/application"
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// TruncatedHeader is set on pins and tiles responses missing pins, so that clients can ask to zoom in
+const TruncatedHeader = "X-Truncated"
+
 func GetPins(e *core.RequestEvent) error {
+	pins, err := getPinsFromRequest(e)
+	if err != nil {
+		return err
+	}
+
+	return e.JSON(http.StatusOK, pins)
+}
+
+// GetPinsGeoJSON returns the pins of GetPins as a GeoJSON feature collection
+func GetPinsGeoJSON(e *core.RequestEvent) error {
+	pins, err := getPinsFromRequest(e)
+	if err != nil {
+		return err
+	}
+
+	features := make([]geoJSONFeature, len(pins))
+	for i, pin := range pins {
+		features[i] = newGeoJSONPoint("", pin.Loc, pin)
+	}
+
+	return geoJSON(e, features)
+}
+
+// getPinsFromRequest returns the pins matching the query params, or the API error to respond with
+func getPinsFromRequest(e *core.RequestEvent) ([]application.Pin, error) {
 	bounds, err := getBoundsFromQueryParams(e.Request.URL.Query())
 	if err != nil {
-		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid bounds: %v", err), nil)
+		return nil, e.Error(http.StatusBadRequest, fmt.Sprintf("invalid bounds: %v", err), nil)
 	}
 
-	maxTime, err := getMaxTimeFromQueryParams(e.Request.URL.Query())
+	window, err := getTimeWindowFromQueryParams(e.Request.URL.Query())
 	if err != nil {
-		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid max time: %v", err), nil)
+		return nil, e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	zoom, clustered, err := getZoomFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return nil, e.Error(http.StatusBadRequest, fmt.Sprintf("invalid zoom: %v", err), nil)
+	}
+
+	filter, err := getFilterFromQueryParams(e.Request.URL.Query())
+	if err != nil {
+		return nil, e.Error(http.StatusBadRequest, fmt.Sprintf("invalid filter: %v", err), nil)
 	}
 
 	pinsService, ok := e.App.Store().Get("pinsService").(application.PinsService)
 	if !ok {
-		return e.Error(http.StatusInternalServerError, "pins service not found", nil)
+		return nil, e.Error(http.StatusInternalServerError, "pins service not found", nil)
 	}
 
-	pins, err := pinsService.GetPins(bounds, maxTime)
+	var pins []application.Pin
+	var truncated bool
+	if clustered {
+		pins, truncated, err = pinsService.GetClusters(bounds, window, zoom, filter)
+	} else {
+		pins, truncated, err = pinsService.GetPins(bounds, window, filter)
+	}
 	if err != nil {
-		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get pins: %v", err), nil)
+		return nil, e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get pins: %v", err), nil)
 	}
+	setTruncatedHeader(e, truncated)
 
-	return e.JSON(http.StatusOK, pins)
+	return pins, nil
+}
+
+// setTruncatedHeader tells clients that pins are missing, more events than application.PinsLimit being in bounds
+func setTruncatedHeader(e *core.RequestEvent, truncated bool) {
+	if truncated {
+		e.Response.Header().Set(TruncatedHeader, "true")
+	}
 }
 
 func getBoundsFromQueryParams(queryParams url.Values) (application.Bounds, error) {
@@ -64,12 +117,98 @@ func getBoundsFromQueryParams(queryParams url.Values) (application.Bounds, error
 	}, nil
 }
 
-func getMaxTimeFromQueryParams(queryParams url.Values) (time.Time, error) {
-	maxTimeStr := queryParams.Get("max_time")
-	maxTime, err := time.Parse(time.RFC3339, maxTimeStr)
+// getTimeWindowFromQueryParams reads the from and to dates, from defaulting to now (or to, if it is earlier).
+// max_time is accepted as an alias of to.
+func getTimeWindowFromQueryParams(queryParams url.Values) (application.TimeWindow, error) {
+	toStr := queryParams.Get("to")
+	if toStr == "" {
+		toStr = queryParams.Get("max_time")
+	}
+	to, err := time.Parse(time.RFC3339, toStr)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid max time: %w", err)
+		return application.TimeWindow{}, fmt.Errorf("invalid to: %w", err)
 	}
 
-	return maxTime, nil
+	window := application.TimeWindow{From: time.Now(), To: to}
+	if window.To.Before(window.From) {
+		window.From = window.To
+	}
+
+	if fromStr := queryParams.Get("from"); fromStr != "" {
+		window.From, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return application.TimeWindow{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	return window, window.Validate()
+}
+
+// getZoomFromQueryParams returns the optional zoom level, and whether it was provided
+func getZoomFromQueryParams(queryParams url.Values) (int, bool, error) {
+	zoomStr := queryParams.Get("zoom")
+	if zoomStr == "" {
+		return 0, false, nil
+	}
+
+	zoom, err := strconv.Atoi(zoomStr)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if zoom < 0 || zoom > 24 {
+		return 0, false, fmt.Errorf("zoom must be between 0 and 24, got %d", zoom)
+	}
+
+	return zoom, true, nil
+}
+
+// getFilterFromQueryParams reads the optional filter params,
+// lists being comma separated values: kinds, exclude_kinds and genres, as well as free and max_price
+func getFilterFromQueryParams(queryParams url.Values) (application.EventFilter, error) {
+	filter := application.EventFilter{
+		Kinds:        kindsFromQueryParam(queryParams, "kinds"),
+		ExcludeKinds: kindsFromQueryParam(queryParams, "exclude_kinds"),
+		Genres:       listFromQueryParam(queryParams, "genres"),
+	}
+
+	if freeStr := queryParams.Get("free"); freeStr != "" {
+		free, err := strconv.ParseBool(freeStr)
+		if err != nil {
+			return application.EventFilter{}, fmt.Errorf("invalid free: %w", err)
+		}
+		filter.FreeOnly = free
+	}
+
+	if maxPriceStr := queryParams.Get("max_price"); maxPriceStr != "" {
+		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+		if err != nil {
+			return application.EventFilter{}, fmt.Errorf("invalid max price: %w", err)
+		}
+		filter.MaxPrice = &maxPrice
+	}
+
+	return filter, filter.Validate()
+}
+
+// listFromQueryParam returns the comma separated values of a param, which may also be repeated
+func listFromQueryParam(queryParams url.Values, name string) []string {
+	values := []string{}
+	for _, param := range queryParams[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func kindsFromQueryParam(queryParams url.Values, name string) []application.Kind {
+	values := listFromQueryParam(queryParams, name)
+	kinds := make([]application.Kind, len(values))
+	for i, value := range values {
+		kinds[i] = application.Kind(value)
+	}
+	return kinds
 }
@@ -0,0 +1,109 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type searchResultResponse struct {
+	eventResponse
+	Snippet string `json:"snippet"`
+}
+
+// Search returns the events matching the q text, from the most relevant.
+// Results can be restricted to bounds (west,south,east,north) and to a from/to time window,
+// from defaulting to now and the window being open-ended without to.
+func Search(e *core.RequestEvent) error {
+	queryParams := e.Request.URL.Query()
+
+	bounds, err := getOptionalBoundsFromQueryParam(queryParams, "bounds")
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid bounds: %v", err), nil)
+	}
+
+	window, err := getOpenTimeWindowFromQueryParams(queryParams)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	eventsService, ok := e.App.Store().Get("eventsService").(application.EventsService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "events service not found", nil)
+	}
+
+	results, err := eventsService.Search(application.SearchQuery{
+		Text:   queryParams.Get("q"),
+		Bounds: bounds,
+		Window: window,
+	})
+	if errors.Is(err, application.ErrEmptySearch) {
+		return e.Error(http.StatusBadRequest, "q must contain at least one word", nil)
+	}
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to search events: %v", err), nil)
+	}
+
+	response := make([]searchResultResponse, len(results))
+	for i, result := range results {
+		response[i] = searchResultResponse{
+			eventResponse: newEventResponse(result.EventRecord),
+			Snippet:       result.Snippet,
+		}
+	}
+
+	return e.JSON(http.StatusOK, response)
+}
+
+// getOptionalBoundsFromQueryParam reads bounds as comma separated west,south,east,north values, nil if absent
+func getOptionalBoundsFromQueryParam(queryParams url.Values, name string) (*application.Bounds, error) {
+	boundsStr := queryParams.Get(name)
+	if boundsStr == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(boundsStr, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("expected west,south,east,north, got %q", boundsStr)
+	}
+
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinate %q: %w", part, err)
+		}
+		values[i] = value
+	}
+
+	return &application.Bounds{West: values[0], South: values[1], East: values[2], North: values[3]}, nil
+}
+
+// getOpenTimeWindowFromQueryParams reads the optional from and to dates, from defaulting to now and to to none
+func getOpenTimeWindowFromQueryParams(queryParams url.Values) (application.TimeWindow, error) {
+	window := application.TimeWindow{From: time.Now()}
+
+	var err error
+	if fromStr := queryParams.Get("from"); fromStr != "" {
+		window.From, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return application.TimeWindow{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if toStr := queryParams.Get("to"); toStr != "" {
+		window.To, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return application.TimeWindow{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	return window, window.Validate()
+}
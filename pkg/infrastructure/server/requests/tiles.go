@@ -0,0 +1,151 @@
+package requests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/mvt"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// tilesMaxAge is how long clients may use a tile without revalidating its ETag
+const tilesMaxAge = time.Minute
+
+// GetTile returns the pins of a /api/tiles/{z}/{x}/{y}.mvt tile as a Mapbox Vector Tile,
+// with kind and amount attributes, pins being clustered up to application.ClusterMaxZoom.
+// Tiles accept the filters of /api/pins and an optional from/to time window,
+// holding all the stored events by default as ended events are deleted every minute.
+// Their ETag changes with the events version, so that clients revalidate tiles without them being rebuilt.
+func GetTile(e *core.RequestEvent) error {
+	tile, err := getTileFromPathValues(e)
+	if err != nil {
+		return e.Error(http.StatusNotFound, fmt.Sprintf("invalid tile: %v", err), nil)
+	}
+
+	queryParams := e.Request.URL.Query()
+
+	window, err := getTileTimeWindowFromQueryParams(queryParams)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid time window: %v", err), nil)
+	}
+
+	filter, err := getFilterFromQueryParams(queryParams)
+	if err != nil {
+		return e.Error(http.StatusBadRequest, fmt.Sprintf("invalid filter: %v", err), nil)
+	}
+
+	tilesService, ok := e.App.Store().Get("tilesService").(application.TilesService)
+	if !ok {
+		return e.Error(http.StatusInternalServerError, "tiles service not found", nil)
+	}
+
+	version, err := tilesService.Version()
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get events version: %v", err), nil)
+	}
+
+	etag := tileETag(version, tile, queryParams)
+	e.Response.Header().Set("ETag", etag)
+	e.Response.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(tilesMaxAge.Seconds())))
+	if matchesETag(e.Request.Header.Get("If-None-Match"), etag) {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	pins, truncated, err := tilesService.GetTile(tile, window, filter)
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to get pins: %v", err), nil)
+	}
+	setTruncatedHeader(e, truncated)
+
+	features := make([]mvt.Feature, len(pins))
+	for i, pin := range pins {
+		x, y := tile.Project(pin.Loc)
+		features[i] = mvt.Feature{
+			X: x,
+			Y: y,
+			Properties: map[string]any{
+				"kind":   string(pin.Kind),
+				"amount": pin.Amount,
+			},
+		}
+	}
+
+	body, err := mvt.Encode(mvt.Layer{Name: "pins", Extent: application.TileExtent, Features: features})
+	if err != nil {
+		return e.Error(http.StatusInternalServerError, fmt.Sprintf("failed to encode tile: %v", err), nil)
+	}
+
+	return e.Blob(http.StatusOK, mvt.ContentType, body)
+}
+
+func getTileFromPathValues(e *core.RequestEvent) (application.Tile, error) {
+	yStr, ok := strings.CutSuffix(e.Request.PathValue("y"), ".mvt")
+	if !ok {
+		return application.Tile{}, fmt.Errorf("only .mvt tiles are served")
+	}
+
+	z, err := strconv.Atoi(e.Request.PathValue("z"))
+	if err != nil {
+		return application.Tile{}, fmt.Errorf("invalid z: %w", err)
+	}
+
+	x, err := strconv.Atoi(e.Request.PathValue("x"))
+	if err != nil {
+		return application.Tile{}, fmt.Errorf("invalid x: %w", err)
+	}
+
+	y, err := strconv.Atoi(yStr)
+	if err != nil {
+		return application.Tile{}, fmt.Errorf("invalid y: %w", err)
+	}
+
+	tile := application.Tile{Z: z, X: x, Y: y}
+	return tile, tile.Validate()
+}
+
+// getTileTimeWindowFromQueryParams reads the optional from and to dates, the window being unbounded by default
+// so that a tile only changes with the stored events
+func getTileTimeWindowFromQueryParams(queryParams url.Values) (application.TimeWindow, error) {
+	var window application.TimeWindow
+
+	var err error
+	if fromStr := queryParams.Get("from"); fromStr != "" {
+		window.From, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return application.TimeWindow{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if toStr := queryParams.Get("to"); toStr != "" {
+		window.To, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return application.TimeWindow{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	return window, window.Validate()
+}
+
+// tileETag identifies the content of a tile, which only depends on the stored events and the request
+func tileETag(version int64, tile application.Tile, queryParams url.Values) string {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%d|%d/%d/%d|%s", version, tile.Z, tile.X, tile.Y, queryParams.Encode()))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// matchesETag reports whether an If-None-Match header value matches etag, weak comparison being used
+func matchesETag(ifNoneMatch string, etag string) bool {
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
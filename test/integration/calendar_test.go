@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarGetEventSuccess(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	price := 0.0
+	priceCurrency := "EUR"
+	begin := time.Date(2030, 6, 21, 19, 0, 0, 0, time.UTC)
+	event := application.Event{
+		Name:          "Fête de la musique",
+		Kind:          application.KindConcert,
+		Genres:        []string{"Jazz"},
+		Begin:         begin,
+		End:           begin.Add(3 * time.Hour),
+		Loc:           application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+		Place:         "Place de la République",
+		Address:       "75011 Paris",
+		Price:         &price,
+		PriceCurrency: &priceCurrency,
+		Source:        "https://example.com/fete",
+	}
+
+	resp, err := putEvents(t, []application.Event{event})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	record, err := app.FindFirstRecordByData("events", "source", event.Source)
+	require.NoError(t, err)
+
+	resp, body := getCalendar(t, fmt.Sprintf("/api/events/%s.ics", record.Id))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, fmt.Sprintf(`attachment; filename="%s.ics"`, record.Id), resp.Header.Get("Content-Disposition"))
+
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:%s@sortir.in", record.Id),
+		"DTSTART:20300621T190000Z",
+		"DTEND:20300621T220000Z",
+		"SUMMARY:Fête de la musique",
+		`LOCATION:Place de la République\, 75011 Paris`,
+		"GEO:48.8566;2.3522",
+		"URL:https://example.com/fete",
+		"CATEGORIES:concert,Jazz",
+		"DESCRIPTION:Gratuit",
+		"END:VCALENDAR",
+	} {
+		require.Contains(t, body, line+"\r\n")
+	}
+}
+
+func TestCalendarGetEventNotFound(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	resp, _ := getCalendar(t, "/api/events/unknown.ics")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCalendarGetFeedSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	now := time.Now()
+	paris := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Concert", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: paris, Kind: application.KindConcert},
+		{Name: "Later concert", Begin: now.Add(24 * 30 * time.Hour), End: now.Add(24*30*time.Hour + time.Hour), Loc: paris, Kind: application.KindConcert},
+		{Name: "Theater", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: paris, Kind: application.KindTheater},
+		{Name: "Far away concert", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: application.EventLocation{Lat: 45.764, Lon: 4.8357}, Kind: application.KindConcert},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	params := url.Values{"bounds": {"2,48,3,49"}, "kinds": {"concert"}}
+	resp, body := getCalendar(t, "/api/calendar.ics?"+params.Encode())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, body, "REFRESH-INTERVAL;VALUE=DURATION:PT6H\r\n")
+	require.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+	require.Contains(t, body, "SUMMARY:Concert\r\n")
+	require.Contains(t, body, "SUMMARY:Later concert\r\n")
+}
+
+func TestCalendarGetFeedInvalidParams(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	for name, params := range map[string]url.Values{
+		"missing bounds": {"kinds": {"concert"}},
+		"invalid bounds": {"bounds": {"2,48,3"}},
+		"invalid kind":   {"bounds": {"2,48,3,49"}, "kinds": {"opera"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, _ := getCalendar(t, "/api/calendar.ics?"+params.Encode())
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func getCalendar(t *testing.T, path string) (*http.Response, string) {
+	t.Helper()
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", PORT, path))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if resp.StatusCode == http.StatusOK {
+		require.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	}
+	return resp, string(body)
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/client/pb"
+)
+
+func TestCollectionRunsSave(t *testing.T) {
+	app := setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), API_KEY)
+
+	startedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Millisecond)
+	run := application.CollectionRun{
+		Name:      "populate",
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(time.Minute),
+		Locations: []application.LocationRun{
+			{
+				City: "Paris",
+				Collectors: []application.CollectorRun{
+					{Collector: "paris", Events: 12, Invalid: 2, DurationMs: 1500},
+					{Collector: "bobine", Error: "unexpected status code: 503"},
+				},
+				Events:    10,
+				Inserted:  4,
+				Updated:   1,
+				Unchanged: 3,
+				Skipped:   2,
+			},
+			{City: "Lyon", Error: "all collectors failed"},
+		},
+		Cache: application.CacheStats{Hits: 5, Revalidated: 2, Misses: 1},
+	}
+
+	require.NoError(t, client.SaveRun(context.Background(), run))
+
+	records, err := app.FindAllRecords("collection_runs")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	record := records[0]
+	require.Equal(t, "populate", record.GetString("name"))
+	require.Equal(t, startedAt, record.GetDateTime("started_at").Time())
+	require.Equal(t, startedAt.Add(time.Minute), record.GetDateTime("ended_at").Time())
+	require.Equal(t, 2, record.GetInt("locations"))
+	require.Equal(t, 1, record.GetInt("succeeded"))
+	require.Equal(t, 10, record.GetInt("events"))
+	require.Equal(t, 2, record.GetInt("invalid"))
+	require.Equal(t, 4, record.GetInt("inserted"))
+	require.Equal(t, 1, record.GetInt("updated"))
+	require.Equal(t, 3, record.GetInt("unchanged"))
+	require.Equal(t, 2, record.GetInt("skipped"))
+	require.Equal(t, 1, record.GetInt("errors"))
+	require.Equal(t, 5, record.GetInt("cache_hits"))
+	require.Equal(t, 2, record.GetInt("cache_revalidated"))
+	require.Equal(t, 1, record.GetInt("cache_misses"))
+
+	var collectors []application.CollectorTotals
+	require.NoError(t, json.Unmarshal([]byte(record.GetString("collectors")), &collectors))
+	require.Equal(t, []application.CollectorTotals{
+		{Collector: "paris", Locations: 1, Events: 12, Invalid: 2},
+		{Collector: "bobine", Locations: 1, Errors: 1},
+	}, collectors)
+
+	var cities []application.LocationRun
+	require.NoError(t, json.Unmarshal([]byte(record.GetString("cities")), &cities))
+	require.Equal(t, run.Locations, cities)
+}
+
+func TestCollectionRunsUnauthorized(t *testing.T) {
+	setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), "wrong-api-key")
+
+	err := client.SaveRun(context.Background(), application.CollectionRun{Name: "populate"})
+	require.ErrorContains(t, err, "401")
+}
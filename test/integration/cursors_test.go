@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leorolland/sortir.in/pkg/infrastructure/client/pb"
+)
+
+func TestCursorsSave(t *testing.T) {
+	app := setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), API_KEY)
+
+	cursors, err := client.Cursors(context.Background(), "Paris")
+	require.NoError(t, err)
+	require.Empty(t, cursors)
+
+	require.NoError(t, client.SaveCursors(context.Background(), "Paris", map[string]string{"paris": "2025-11-20T09:00:00Z", "bobine": "2025-11-22T00:00:00Z"}))
+	require.NoError(t, client.SaveCursors(context.Background(), "Lyon", map[string]string{"bobine": "2025-11-23T00:00:00Z"}))
+
+	// the cursors of the sources missing from the body are kept
+	require.NoError(t, client.SaveCursors(context.Background(), "Paris", map[string]string{"paris": "2025-11-21T07:30:00Z"}))
+
+	cursors, err = client.Cursors(context.Background(), "Paris")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"paris": "2025-11-21T07:30:00Z", "bobine": "2025-11-22T00:00:00Z"}, cursors)
+
+	records, err := app.FindAllRecords("collector_cursors")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+}
+
+func TestCursorsUnauthorized(t *testing.T) {
+	setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), "wrong-api-key")
+
+	_, err := client.Cursors(context.Background(), "Paris")
+	require.ErrorContains(t, err, "401")
+
+	err = client.SaveCursors(context.Background(), "Paris", map[string]string{"paris": "2025-11-21T07:30:00Z"})
+	require.ErrorContains(t, err, "401")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -65,26 +66,27 @@ func TestEventsPutSuccess(t *testing.T) {
 		defer resp.Body.Close()
 
 		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assertSaveReport(t, application.SaveReport{Inserted: 2, Rejected: []application.EventSaveResult{}}, resp)
 
 		records, err := app.FindAllRecords("events")
 		require.NoError(t, err)
 
 		assertEqualEvents(t, events, records)
 
-		// Update the first event
+		// Update the first event from its own source, slightly moving it
 		updatedEvent := application.Event{
 			Name:  events[0].Name,
 			Begin: events[0].Begin,
 			End:   events[0].End,
 			Loc: application.EventLocation{
-				Lat: events[0].Loc.Lat + 0.0100,
-				Lon: events[0].Loc.Lon + 0.0100,
+				Lat: events[0].Loc.Lat + 0.0005,
+				Lon: events[0].Loc.Lon + 0.0005,
 			},
 			Place:         "updated place",
 			Address:       "updated address",
 			Price:         nil,
 			PriceCurrency: nil,
-			Source:        "updated source",
+			Source:        events[0].Source,
 			Img:           "updated img",
 			Genres:        []string{"updated genre"},
 			Kind:          "updated kind",
@@ -95,6 +97,7 @@ func TestEventsPutSuccess(t *testing.T) {
 		defer resp.Body.Close()
 
 		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assertSaveReport(t, application.SaveReport{Updated: 1, Rejected: []application.EventSaveResult{}}, resp)
 
 		records, err = app.FindAllRecords("events")
 		require.NoError(t, err)
@@ -103,7 +106,192 @@ func TestEventsPutSuccess(t *testing.T) {
 	})
 }
 
+func TestEventsPutMergesSameEventFromAnotherSource(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	price := 10.0
+	priceCurrency := "EUR"
+	begin := time.Now().Add(24 * time.Hour)
+
+	event := application.Event{
+		Name:          "Concert Piano",
+		Kind:          application.KindConcert,
+		Genres:        []string{"Classical"},
+		Begin:         begin,
+		End:           begin.Add(2 * time.Hour),
+		Loc:           application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+		Place:         "Salle Pleyel",
+		Price:         &price,
+		PriceCurrency: &priceCurrency,
+		Source:        "https://a.example.com/concert-piano",
+	}
+	sameEvent := application.Event{
+		Name:    "CONCERT - Piano",
+		Begin:   begin.Add(5 * time.Minute),
+		End:     begin.Add(2 * time.Hour),
+		Loc:     application.EventLocation{Lat: 48.8570, Lon: 2.3525},
+		Address: "252 Rue du Faubourg Saint-Honoré, Paris",
+		Source:  "https://b.example.com/events/42",
+	}
+
+	resp, err := putEvents(t, []application.Event{event})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assertSaveReport(t, application.SaveReport{Inserted: 1, Rejected: []application.EventSaveResult{}}, resp)
+
+	resp, err = putEvents(t, []application.Event{sameEvent})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assertSaveReport(t, application.SaveReport{Updated: 1, Rejected: []application.EventSaveResult{}}, resp)
+
+	records, err := app.FindAllRecords("events")
+	require.NoError(t, err)
+
+	// The richer record is kept, completed with the address of the other source
+	merged := event
+	merged.Address = sameEvent.Address
+	merged.Sources = []string{event.Source, sameEvent.Source}
+	assertEqualEvents(t, []application.Event{merged}, records)
+}
+
+func TestEventsPutUnchanged(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	begin := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	event := application.Event{
+		Name:   "Concert Piano",
+		Kind:   application.KindConcert,
+		Genres: []string{"Classical"},
+		Begin:  begin,
+		End:    begin.Add(2 * time.Hour),
+		Loc:    application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+		Place:  "Salle Pleyel",
+		Source: "https://a.example.com/concert-piano",
+	}
+
+	resp, err := putEvents(t, []application.Event{event})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assertSaveReport(t, application.SaveReport{Inserted: 1, Rejected: []application.EventSaveResult{}}, resp)
+
+	record, err := app.FindFirstRecordByData("events", "name", event.Name)
+	require.NoError(t, err)
+
+	// Collecting the same event again writes nothing
+	resp, err = putEvents(t, []application.Event{event})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assertSaveReport(t, application.SaveReport{Unchanged: 1, Rejected: []application.EventSaveResult{}}, resp)
+
+	unchanged, err := app.FindRecordById("events", record.Id)
+	require.NoError(t, err)
+	assertEqualEvent(t, event, unchanged)
+}
+
+func TestEventsPutReport(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	now := time.Now()
+	events := []application.Event{
+		{
+			Name:  "Ended Event",
+			Begin: now.Add(-25 * time.Hour),
+			End:   now.Add(-24 * time.Hour),
+			Loc:   application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+			Kind:  application.KindMovie,
+		},
+		{
+			Name:   "Valid Event",
+			Begin:  now.Add(24 * time.Hour),
+			End:    now.Add(25 * time.Hour),
+			Loc:    application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+			Kind:   application.KindMovie,
+			Genres: []string{"Drama"},
+		},
+		{
+			Name:  "Too Long Event",
+			Begin: now.Add(24 * time.Hour),
+			End:   now.Add(24 * time.Hour * 20),
+			Loc:   application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+			Kind:  application.KindExhibitions,
+		},
+	}
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assertSaveReport(t, application.SaveReport{
+		Inserted: 1,
+		Skipped:  2,
+		Rejected: []application.EventSaveResult{
+			{Index: 0, Status: application.SaveStatusSkipped, Reason: application.ErrEventEnded.Error()},
+			{Index: 2, Status: application.SaveStatusSkipped, Reason: application.ErrEventTooLong.Error()},
+		},
+	}, resp)
+
+	records, err := app.FindAllRecords("events")
+	require.NoError(t, err)
+
+	assertEqualEvents(t, events[1:2], records)
+}
+
+func TestEventsPutUnauthorized(t *testing.T) {
+	testCases := map[string]string{
+		"without API key":    "",
+		"with wrong API key": "wrong-api-key",
+	}
+
+	for name, apiKey := range testCases {
+		t.Run(name, func(t *testing.T) {
+			app := setupTestPocketBase(t)
+
+			events := applicationtest.MustValidateEvents(t, []application.Event{
+				{
+					Name:  "Test Event",
+					Begin: time.Now().Add(24 * time.Hour),
+					End:   time.Now().Add(25 * time.Hour),
+					Loc:   application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+					Kind:  application.KindMovie,
+				},
+			})
+
+			resp, err := putEventsWithAPIKey(t, events, apiKey)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+			records, err := app.FindAllRecords("events")
+			require.NoError(t, err)
+			require.Empty(t, records)
+		})
+	}
+}
+
+func TestEventsCollectionIsReadOnly(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/collections/events/records", PORT)
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(`{"name": "Test Event"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
 func putEvents(t *testing.T, events []application.Event) (*http.Response, error) {
+	return putEventsWithAPIKey(t, events, API_KEY)
+}
+
+func putEventsWithAPIKey(t *testing.T, events []application.Event, apiKey string) (*http.Response, error) {
 	eventsJSON, err := json.Marshal(events)
 	require.NoError(t, err)
 
@@ -111,7 +299,131 @@ func putEvents(t *testing.T, events []application.Event) (*http.Response, error)
 	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(eventsJSON))
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
 
 	client := &http.Client{}
 	return client.Do(req)
 }
+
+func assertSaveReport(t *testing.T, expected application.SaveReport, resp *http.Response) {
+	t.Helper()
+
+	var report application.SaveReport
+	err := json.NewDecoder(resp.Body).Decode(&report)
+	require.NoError(t, err)
+
+	require.Equal(t, expected, report)
+}
+
+func TestEventsGetByIDSuccess(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	price := 12.5
+	priceCurrency := "EUR"
+	begin := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	event := application.Event{
+		Name:          "Test Event",
+		Kind:          application.KindConcert,
+		Genres:        []string{"Jazz"},
+		Begin:         begin,
+		End:           begin.Add(2 * time.Hour),
+		Loc:           application.EventLocation{Lat: 48.8566, Lon: 2.3522},
+		Place:         "Test Place",
+		Address:       "Test Address",
+		Price:         &price,
+		PriceCurrency: &priceCurrency,
+		Source:        "https://example.com",
+		Img:           "https://example.com/image.jpg",
+	}
+
+	resp, err := putEvents(t, []application.Event{event})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	records, err := app.FindAllRecords("events")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	resp, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/events/%s", PORT, records[0].Id))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+	require.Equal(t, map[string]any{
+		"id":             records[0].Id,
+		"name":           "Test Event",
+		"kind":           "concert",
+		"genres":         []any{"Jazz"},
+		"begin":          begin.Format(time.RFC3339),
+		"end":            begin.Add(2 * time.Hour).Format(time.RFC3339),
+		"loc":            map[string]any{"lat": 48.8566, "lon": 2.3522},
+		"place":          "Test Place",
+		"address":        "Test Address",
+		"price":          12.5,
+		"price_currency": "EUR",
+		"source":         "https://example.com",
+		"sources":        []any{"https://example.com"},
+		"img":            "https://example.com/image.jpg",
+	}, actual)
+}
+
+func TestEventsGetByIDNotFound(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/events/unknown", PORT))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestEventsGetAtLocationSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	now := time.Now()
+	loc := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Later Event", Begin: now.Add(48 * time.Hour), End: now.Add(49 * time.Hour), Loc: loc, Kind: application.KindMovie},
+		{Name: "Sooner Event", Begin: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour), Loc: loc, Kind: application.KindMovie},
+		{Name: "Too Late Event", Begin: now.Add(24 * 5 * time.Hour), End: now.Add(24*5*time.Hour + time.Hour), Loc: loc, Kind: application.KindMovie},
+		{Name: "Nearby Event", Begin: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour), Loc: application.EventLocation{Lat: 48.8576, Lon: 2.3522}, Kind: application.KindMovie},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/events?lat=%s&lon=%s&max_time=%s", PORT,
+		strconv.FormatFloat(loc.Lat, 'f', -1, 64),
+		strconv.FormatFloat(loc.Lon, 'f', -1, 64),
+		now.Add(72*time.Hour).UTC().Format(time.RFC3339),
+	)
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual []struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+	require.Len(t, actual, 2)
+	require.Equal(t, "Sooner Event", actual[0].Name)
+	require.Equal(t, "Later Event", actual[1].Name)
+}
+
+func TestEventsGetAtLocationInvalidParams(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/events?lat=abc&lon=2.35&max_time=%s", PORT, time.Now().UTC().Format(time.RFC3339)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
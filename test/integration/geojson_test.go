@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/stretchr/testify/require"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Type     string `json:"type"`
+		ID       string `json:"id"`
+		Geometry struct {
+			Type        string     `json:"type"`
+			Coordinates [2]float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	} `json:"features"`
+}
+
+func TestGeoJSONGetSuccess(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	now := time.Now()
+	loc := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Concert", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: loc, Kind: application.KindConcert},
+		{Name: "Theater", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: loc, Kind: application.KindTheater},
+		{Name: "Far away", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: application.EventLocation{Lat: 45.764, Lon: 4.8357}, Kind: application.KindConcert},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	concert, err := app.FindFirstRecordByData("events", "name", "Concert")
+	require.NoError(t, err)
+
+	params := url.Values{
+		"north": {"49"}, "south": {"48"}, "east": {"3"}, "west": {"2"},
+		"to":    {now.Add(24 * time.Hour).UTC().Format(time.RFC3339)},
+		"kinds": {"concert"},
+	}
+
+	t.Run("pins", func(t *testing.T) {
+		collection := getGeoJSON(t, "/api/pins.geojson", params)
+		require.Len(t, collection.Features, 1)
+
+		feature := collection.Features[0]
+		require.Equal(t, "Feature", feature.Type)
+		require.Equal(t, "Point", feature.Geometry.Type)
+		require.Equal(t, [2]float64{loc.Lon, loc.Lat}, feature.Geometry.Coordinates)
+		require.Equal(t, "concert", feature.Properties["kind"])
+		require.Equal(t, 1.0, feature.Properties["amount"])
+	})
+
+	t.Run("events", func(t *testing.T) {
+		collection := getGeoJSON(t, "/api/events.geojson", params)
+		require.Len(t, collection.Features, 1)
+
+		feature := collection.Features[0]
+		require.Equal(t, concert.Id, feature.ID)
+		require.Equal(t, [2]float64{loc.Lon, loc.Lat}, feature.Geometry.Coordinates)
+		require.Equal(t, "Concert", feature.Properties["name"])
+		require.Equal(t, "concert", feature.Properties["kind"])
+	})
+}
+
+func TestGeoJSONGetInvalidParams(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	for _, path := range []string{"/api/pins.geojson", "/api/events.geojson"} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s?north=49&south=48&east=3&west=2&to=%s&kinds=opera", PORT, path, time.Now().UTC().Format(time.RFC3339)))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func getGeoJSON(t *testing.T, path string, params url.Values) geoJSONFeatureCollection {
+	t.Helper()
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s?%s", PORT, path, params.Encode()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/geo+json", resp.Header.Get("Content-Type"))
+
+	var collection geoJSONFeatureCollection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&collection))
+	require.Equal(t, "FeatureCollection", collection.Type)
+	return collection
+}
@@ -19,6 +19,8 @@ import (
 
 const PORT = 8035
 
+const API_KEY = "test-api-key"
+
 func setupTestPocketBase(t *testing.T) *pocketbase.PocketBase {
 	t.Helper()
 
@@ -44,6 +46,7 @@ func setupTestPocketBase(t *testing.T) *pocketbase.PocketBase {
 		require.NoError(t, err)
 	})
 
+	t.Setenv(server.IngestAPIKeyEnv, API_KEY)
 	server.RegisterApp(app)
 
 	os.Args[1] = "serve"
@@ -78,6 +81,7 @@ func assertEqualEvent(t *testing.T, expected application.Event, actual *core.Rec
 		require.Zero(t, actual.GetString("price_currency"))
 	}
 	require.Equal(t, expected.Source, actual.GetString("source"))
+	require.Equal(t, expected.AllSources(), actual.GetStringSlice("sources"))
 	require.Equal(t, expected.Img, actual.GetString("img"))
 	require.Equal(t, expected.Genres, actual.GetStringSlice("genres"))
 	require.Equal(t, string(expected.Kind), actual.GetString("kind"))
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/infrastructure/client/pb"
+)
+
+func TestLocationsOrderedByPriorityAndStaleness(t *testing.T) {
+	app := setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), API_KEY)
+
+	locations, err := client.EnabledLocations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, locations, 30)
+	require.Equal(t, application.CollectLocation{City: "Aix-en-Provence", Lat: 43.5297, Lon: 5.4474, Radius: 5.0}, locations[0])
+
+	nantes, err := app.FindFirstRecordByData("locations", "name", "Nantes")
+	require.NoError(t, err)
+	nantes.Set("priority", 1)
+	require.NoError(t, app.Save(nantes))
+
+	lyon, err := app.FindFirstRecordByData("locations", "name", "Lyon")
+	require.NoError(t, err)
+	lyon.Set("enabled", false)
+	require.NoError(t, app.Save(lyon))
+
+	require.NoError(t, client.MarkCollected(context.Background(), "Aix-en-Provence", time.Now()))
+	require.NoError(t, client.MarkCollected(context.Background(), "Amiens", time.Now().Add(-time.Hour)))
+
+	locations, err = client.EnabledLocations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, locations, 29)
+	require.Equal(t, "Nantes", locations[0].City)
+	require.Equal(t, "Angers", locations[1].City)
+	require.Equal(t, "Amiens", locations[27].City)
+	require.Equal(t, "Aix-en-Provence", locations[28].City)
+
+	aix, err := app.FindFirstRecordByData("locations", "name", "Aix-en-Provence")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), aix.GetDateTime("last_collected_at").Time(), time.Minute)
+}
+
+func TestLocationsMarkCollectedNotFound(t *testing.T) {
+	setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), API_KEY)
+
+	err := client.MarkCollected(context.Background(), "Atlantis", time.Now())
+	require.ErrorIs(t, err, application.ErrLocationNotFound)
+}
+
+func TestLocationsUnauthorized(t *testing.T) {
+	setupTestPocketBase(t)
+	client := pb.NewPBClient(fmt.Sprintf("http://127.0.0.1:%d", PORT), "wrong-api-key")
+
+	_, err := client.EnabledLocations(context.Background())
+	require.ErrorContains(t, err, "401")
+
+	err = client.MarkCollected(context.Background(), "Paris", time.Now())
+	require.ErrorContains(t, err, "401")
+}
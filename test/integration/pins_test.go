@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"testing"
 	"time"
@@ -15,6 +16,9 @@ import (
 )
 
 func TestPinsGetSuccess(t *testing.T) {
+	// dates are stored to the second
+	begin := time.Now().Add(time.Hour * 24).UTC().Truncate(time.Second)
+
 	testCases := map[string]struct {
 		events   []application.Event
 		bounds   application.Bounds
@@ -38,7 +42,7 @@ func TestPinsGetSuccess(t *testing.T) {
 					Name:  "Event outside bounds",
 					Loc:   application.EventLocation{Lat: 48.8, Lon: 2.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
@@ -64,14 +68,14 @@ func TestPinsGetSuccess(t *testing.T) {
 					Name:  "Event outside bounds",
 					Loc:   application.EventLocation{Lat: 48.8, Lon: 2.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 			}),
@@ -84,9 +88,10 @@ func TestPinsGetSuccess(t *testing.T) {
 			maxDate: time.Now().Add(time.Hour * 24 * 4),
 			expected: []application.Pin{
 				{
-					Loc:    application.EventLocation{Lat: 42.8, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.8, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 			},
 		},
@@ -96,21 +101,21 @@ func TestPinsGetSuccess(t *testing.T) {
 					Name:  "Event outside bounds",
 					Loc:   application.EventLocation{Lat: 48.8, Lon: 2.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 1",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 2",
 					Loc:   application.EventLocation{Lat: 42.9, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 			}),
@@ -123,14 +128,16 @@ func TestPinsGetSuccess(t *testing.T) {
 			maxDate: time.Now().Add(time.Hour * 24 * 4),
 			expected: []application.Pin{
 				{
-					Loc:    application.EventLocation{Lat: 42.8, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.8, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 				{
-					Loc:    application.EventLocation{Lat: 42.9, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.9, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 			},
 		},
@@ -140,28 +147,28 @@ func TestPinsGetSuccess(t *testing.T) {
 					Name:  "Event outside bounds",
 					Loc:   application.EventLocation{Lat: 48.8, Lon: 2.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 1",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 2",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 3",
 					Loc:   application.EventLocation{Lat: 42.9, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 			}),
@@ -174,14 +181,16 @@ func TestPinsGetSuccess(t *testing.T) {
 			maxDate: time.Now().Add(time.Hour * 24 * 4),
 			expected: []application.Pin{
 				{
-					Loc:    application.EventLocation{Lat: 42.8, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 2,
+					Loc:       application.EventLocation{Lat: 42.8, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    2,
+					NextBegin: &begin,
 				},
 				{
-					Loc:    application.EventLocation{Lat: 42.9, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.9, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 			},
 		},
@@ -191,28 +200,28 @@ func TestPinsGetSuccess(t *testing.T) {
 					Name:  "Event outside bounds",
 					Loc:   application.EventLocation{Lat: 48.8, Lon: 2.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 1",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 2",
 					Loc:   application.EventLocation{Lat: 42.8, Lon: 1.3},
 					Kind:  application.KindBusiness,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 				{
 					Name:  "Event inside bounds 3",
 					Loc:   application.EventLocation{Lat: 42.9, Lon: 1.3},
 					Kind:  application.KindConcert,
-					Begin: time.Now().Add(time.Hour * 24),
+					Begin: begin,
 					End:   time.Now().Add(time.Hour * 24 * 2),
 				},
 			}),
@@ -225,19 +234,22 @@ func TestPinsGetSuccess(t *testing.T) {
 			maxDate: time.Now().Add(time.Hour * 24 * 4),
 			expected: []application.Pin{
 				{
-					Loc:    application.EventLocation{Lat: 42.8, Lon: 1.3},
-					Kind:   application.KindBusiness,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.8, Lon: 1.3},
+					Kind:      application.KindBusiness,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 				{
-					Loc:    application.EventLocation{Lat: 42.8, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.8, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 				{
-					Loc:    application.EventLocation{Lat: 42.9, Lon: 1.3},
-					Kind:   application.KindConcert,
-					Amount: 1,
+					Loc:       application.EventLocation{Lat: 42.9, Lon: 1.3},
+					Kind:      application.KindConcert,
+					Amount:    1,
+					NextBegin: &begin,
 				},
 			},
 		},
@@ -285,3 +297,316 @@ func getPins(t *testing.T, bounds application.Bounds, maxDate time.Time) (*http.
 	client := &http.Client{}
 	return client.Do(req)
 }
+
+func TestPinsGetClustersSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{
+			Name:  "Concert in Paris 1",
+			Loc:   application.EventLocation{Lat: 48.85, Lon: 2.35},
+			Kind:  application.KindConcert,
+			Begin: time.Now().Add(time.Hour * 24),
+			End:   time.Now().Add(time.Hour * 24 * 2),
+		},
+		{
+			Name:  "Concert in Paris 2",
+			Loc:   application.EventLocation{Lat: 48.87, Lon: 2.33},
+			Kind:  application.KindConcert,
+			Begin: time.Now().Add(time.Hour * 24),
+			End:   time.Now().Add(time.Hour * 24 * 2),
+		},
+		{
+			Name:  "Theater in Paris",
+			Loc:   application.EventLocation{Lat: 48.86, Lon: 2.34},
+			Kind:  application.KindTheater,
+			Begin: time.Now().Add(time.Hour * 24),
+			End:   time.Now().Add(time.Hour * 24 * 2),
+		},
+		{
+			Name:  "Concert in Marseille",
+			Loc:   application.EventLocation{Lat: 43.29, Lon: 5.37},
+			Kind:  application.KindConcert,
+			Begin: time.Now().Add(time.Hour * 24),
+			End:   time.Now().Add(time.Hour * 24 * 2),
+		},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	bounds := application.Bounds{North: 51.1, South: 41.3, East: 9.6, West: -5.2}
+	resp, err = getClusters(t, bounds, time.Now().Add(time.Hour*24*4), 5)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var pins []application.Pin
+	err = json.NewDecoder(resp.Body).Decode(&pins)
+	require.NoError(t, err)
+
+	require.Len(t, pins, 2)
+
+	assert.InDelta(t, 43.29, pins[0].Loc.Lat, 0.0001)
+	assert.InDelta(t, 5.37, pins[0].Loc.Lon, 0.0001)
+	assert.Equal(t, application.KindConcert, pins[0].Kind)
+	assert.Equal(t, 1, pins[0].Amount)
+	assert.Equal(t, map[application.Kind]int{application.KindConcert: 1}, pins[0].Kinds)
+
+	assert.InDelta(t, 48.86, pins[1].Loc.Lat, 0.0001)
+	assert.InDelta(t, 2.34, pins[1].Loc.Lon, 0.0001)
+	assert.Equal(t, application.KindConcert, pins[1].Kind)
+	assert.Equal(t, 3, pins[1].Amount)
+	assert.Equal(t, map[application.Kind]int{application.KindConcert: 2, application.KindTheater: 1}, pins[1].Kinds)
+}
+
+func getClusters(t *testing.T, bounds application.Bounds, maxDate time.Time, zoom int) (*http.Response, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/pins", PORT)
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+
+	query := req.URL.Query()
+	query.Add("north", strconv.FormatFloat(bounds.North, 'f', -1, 64))
+	query.Add("south", strconv.FormatFloat(bounds.South, 'f', -1, 64))
+	query.Add("east", strconv.FormatFloat(bounds.East, 'f', -1, 64))
+	query.Add("west", strconv.FormatFloat(bounds.West, 'f', -1, 64))
+	query.Add("max_time", maxDate.Format(time.RFC3339))
+	query.Add("zoom", strconv.Itoa(zoom))
+
+	req.URL.RawQuery = query.Encode()
+
+	client := &http.Client{}
+	return client.Do(req)
+}
+
+func TestPinsGetFiltersSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	free := 0.0
+	cheap := 15.0
+	expensive := 45.0
+	euro := "EUR"
+	begin := time.Now().Add(time.Hour * 24)
+	end := time.Now().Add(time.Hour * 26)
+
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Free jazz concert", Loc: application.EventLocation{Lat: 48.1, Lon: 2.3}, Kind: application.KindConcert, Genres: []string{"Jazz"}, Price: &free, PriceCurrency: &euro, Begin: begin, End: end},
+		{Name: "Cheap rock concert", Loc: application.EventLocation{Lat: 48.2, Lon: 2.3}, Kind: application.KindConcert, Genres: []string{"Rock", "Pop-rock"}, Price: &cheap, PriceCurrency: &euro, Begin: begin, End: end},
+		{Name: "Expensive play", Loc: application.EventLocation{Lat: 48.3, Lon: 2.3}, Kind: application.KindTheater, Genres: []string{"Comédie"}, Price: &expensive, PriceCurrency: &euro, Begin: begin, End: end},
+		{Name: "Movie without price", Loc: application.EventLocation{Lat: 48.4, Lon: 2.3}, Kind: application.KindMovie, Begin: begin, End: end},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	testCases := map[string]struct {
+		params   url.Values
+		expected []float64 // latitudes of the expected pins
+	}{
+		"without filter": {
+			params:   url.Values{},
+			expected: []float64{48.1, 48.2, 48.3, 48.4},
+		},
+		"with kinds": {
+			params:   url.Values{"kinds": {"concert,theater"}},
+			expected: []float64{48.1, 48.2, 48.3},
+		},
+		"with excluded kinds": {
+			params:   url.Values{"exclude_kinds": {"concert"}},
+			expected: []float64{48.3, 48.4},
+		},
+		"with genre keywords": {
+			params:   url.Values{"genres": {"rock,comédie"}},
+			expected: []float64{48.2, 48.3},
+		},
+		"with genre keywords in another case": {
+			params:   url.Values{"genres": {"JAZZ"}},
+			expected: []float64{48.1},
+		},
+		"with free only": {
+			params:   url.Values{"free": {"true"}},
+			expected: []float64{48.1},
+		},
+		"with max price": {
+			params:   url.Values{"max_price": {"20"}},
+			expected: []float64{48.1, 48.2},
+		},
+		"with kinds and max price": {
+			params:   url.Values{"kinds": {"concert", "theater"}, "max_price": {"20"}, "free": {"false"}},
+			expected: []float64{48.1, 48.2},
+		},
+	}
+
+	bounds := application.Bounds{North: 49, South: 48, East: 3, West: 2}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := getPinsWithParams(t, bounds, end.Add(time.Hour), testCase.params)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var pins []application.Pin
+			err = json.NewDecoder(resp.Body).Decode(&pins)
+			require.NoError(t, err)
+
+			latitudes := []float64{}
+			for _, pin := range pins {
+				latitudes = append(latitudes, pin.Loc.Lat)
+			}
+			assert.ElementsMatch(t, testCase.expected, latitudes)
+		})
+	}
+}
+
+func TestPinsGetFiltersInvalid(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	testCases := map[string]url.Values{
+		"with unknown kind":     {"kinds": {"opera"}},
+		"with invalid free":     {"free": {"maybe"}},
+		"with invalid price":    {"max_price": {"cheap"}},
+		"with negative price":   {"max_price": {"-1"}},
+		"with unknown excluded": {"exclude_kinds": {"opera"}},
+	}
+
+	bounds := application.Bounds{North: 49, South: 48, East: 3, West: 2}
+	for name, params := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := getPinsWithParams(t, bounds, time.Now(), params)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func getPinsWithParams(t *testing.T, bounds application.Bounds, maxDate time.Time, params url.Values) (*http.Response, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/pins", PORT)
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+
+	query := req.URL.Query()
+	query.Add("north", strconv.FormatFloat(bounds.North, 'f', -1, 64))
+	query.Add("south", strconv.FormatFloat(bounds.South, 'f', -1, 64))
+	query.Add("east", strconv.FormatFloat(bounds.East, 'f', -1, 64))
+	query.Add("west", strconv.FormatFloat(bounds.West, 'f', -1, 64))
+	query.Add("max_time", maxDate.Format(time.RFC3339))
+	for name, values := range params {
+		for _, value := range values {
+			query.Add(name, value)
+		}
+	}
+
+	req.URL.RawQuery = query.Encode()
+
+	client := &http.Client{}
+	return client.Do(req)
+}
+
+func TestPinsGetTimeWindowSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	concertBegin := now.Add(time.Hour * 2)
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Ongoing exhibition", Loc: application.EventLocation{Lat: 48.1, Lon: 2.3}, Kind: application.KindExhibitions, Begin: now.Add(-time.Hour * 24 * 2), End: now.Add(time.Hour * 24 * 10)},
+		{Name: "Upcoming concert", Loc: application.EventLocation{Lat: 48.2, Lon: 2.3}, Kind: application.KindConcert, Begin: concertBegin, End: concertBegin.Add(time.Hour * 2)},
+		{Name: "Later concert", Loc: application.EventLocation{Lat: 48.3, Lon: 2.3}, Kind: application.KindConcert, Begin: now.Add(time.Hour * 24 * 12), End: now.Add(time.Hour * 24 * 12).Add(time.Hour * 2)},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	testCases := map[string]struct {
+		params   url.Values
+		expected []application.Pin
+	}{
+		"from now to tomorrow": {
+			params: url.Values{"to": {now.Add(time.Hour * 24).Format(time.RFC3339)}},
+			expected: []application.Pin{
+				{Loc: application.EventLocation{Lat: 48.1, Lon: 2.3}, Kind: application.KindExhibitions, Amount: 1},
+				{Loc: application.EventLocation{Lat: 48.2, Lon: 2.3}, Kind: application.KindConcert, Amount: 1, NextBegin: &concertBegin},
+			},
+		},
+		"in a week": {
+			params: url.Values{
+				"from": {now.Add(time.Hour * 24 * 7).Format(time.RFC3339)},
+				"to":   {now.Add(time.Hour * 24 * 8).Format(time.RFC3339)},
+			},
+			expected: []application.Pin{
+				{Loc: application.EventLocation{Lat: 48.1, Lon: 2.3}, Kind: application.KindExhibitions, Amount: 1},
+			},
+		},
+	}
+
+	bounds := application.Bounds{North: 49, South: 48, East: 3, West: 2}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := getPinsInWindow(t, bounds, testCase.params)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var pins []application.Pin
+			err = json.NewDecoder(resp.Body).Decode(&pins)
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.expected, pins)
+		})
+	}
+}
+
+func TestPinsGetTimeWindowInvalid(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	now := time.Now()
+	testCases := map[string]url.Values{
+		"without to":          {"from": {now.Format(time.RFC3339)}},
+		"with invalid from":   {"from": {"yesterday"}, "to": {now.Format(time.RFC3339)}},
+		"with to before from": {"from": {now.Format(time.RFC3339)}, "to": {now.Add(-time.Hour).Format(time.RFC3339)}},
+	}
+
+	bounds := application.Bounds{North: 49, South: 48, East: 3, West: 2}
+	for name, params := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp, err := getPinsInWindow(t, bounds, params)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func getPinsInWindow(t *testing.T, bounds application.Bounds, params url.Values) (*http.Response, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/pins", PORT)
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+
+	query := req.URL.Query()
+	query.Add("north", strconv.FormatFloat(bounds.North, 'f', -1, 64))
+	query.Add("south", strconv.FormatFloat(bounds.South, 'f', -1, 64))
+	query.Add("east", strconv.FormatFloat(bounds.East, 'f', -1, 64))
+	query.Add("west", strconv.FormatFloat(bounds.West, 'f', -1, 64))
+	for name, values := range params {
+		for _, value := range values {
+			query.Add(name, value)
+		}
+	}
+
+	req.URL.RawQuery = query.Encode()
+
+	client := &http.Client{}
+	return client.Do(req)
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/stretchr/testify/require"
+)
+
+type searchResult struct {
+	Name    string `json:"name"`
+	Snippet string `json:"snippet"`
+}
+
+func TestSearchSuccess(t *testing.T) {
+	app := setupTestPocketBase(t)
+
+	now := time.Now()
+	paris := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	lyon := application.EventLocation{Lat: 45.764, Lon: 4.8357}
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Soirée Électro", Genres: []string{"Techno"}, Place: "Le Café", Begin: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour), Loc: paris, Kind: application.KindConcert},
+		{Name: "Jazz au café", Place: "La Cave", Begin: now.Add(48 * time.Hour), End: now.Add(49 * time.Hour), Loc: paris, Kind: application.KindConcert},
+		{Name: "Concert <b>Jazz</b>", Place: "Salle", Begin: now.Add(24 * time.Hour), End: now.Add(25 * time.Hour), Loc: lyon, Kind: application.KindConcert},
+		{Name: "Cinéma", Genres: []string{"Jazz"}, Place: "Ciné", Begin: now.Add(24 * 10 * time.Hour), End: now.Add(24*10*time.Hour + time.Hour), Loc: paris, Kind: application.KindMovie},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("accent insensitive", func(t *testing.T) {
+		actual := search(t, url.Values{"q": {"SOIREE electro"}})
+		require.Len(t, actual, 1)
+		require.Equal(t, "Soirée Électro", actual[0].Name)
+		require.Equal(t, "<mark>Soirée</mark> <mark>Électro</mark>", actual[0].Snippet)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		actual := search(t, url.Values{"q": {"caf"}})
+		require.Len(t, actual, 2)
+		// name matches rank before place matches
+		require.Equal(t, "Jazz au café", actual[0].Name)
+		require.Equal(t, "Soirée Électro", actual[1].Name)
+	})
+
+	t.Run("escaped snippet", func(t *testing.T) {
+		actual := search(t, url.Values{"q": {"jazz"}, "to": {now.Add(72 * time.Hour).UTC().Format(time.RFC3339)}})
+		require.Len(t, actual, 2)
+		require.ElementsMatch(t, []string{"Jazz au café", "Concert <b>Jazz</b>"}, []string{actual[0].Name, actual[1].Name})
+		for _, result := range actual {
+			if result.Name == "Concert <b>Jazz</b>" {
+				require.Equal(t, "Concert &lt;b&gt;<mark>Jazz</mark>&lt;/b&gt;", result.Snippet)
+			}
+		}
+	})
+
+	t.Run("open-ended window", func(t *testing.T) {
+		actual := search(t, url.Values{"q": {"jazz"}})
+		require.Len(t, actual, 3)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		actual := search(t, url.Values{"q": {"jazz"}, "bounds": {"2.3,48.8,2.4,48.9"}})
+		require.Len(t, actual, 2)
+		require.ElementsMatch(t, []string{"Jazz au café", "Cinéma"}, []string{actual[0].Name, actual[1].Name})
+	})
+
+	t.Run("updated events are reindexed", func(t *testing.T) {
+		records, err := app.FindRecordsByFilter("events", "name = 'Cinéma'", "", 1, 0)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		records[0].Set("genres", []string{"Drame"})
+		require.NoError(t, app.SaveNoValidate(records[0]))
+
+		actual := search(t, url.Values{"q": {"drame"}})
+		require.Len(t, actual, 1)
+		require.Equal(t, "Cinéma", actual[0].Name)
+
+		actual = search(t, url.Values{"q": {"jazz"}, "bounds": {"2.3,48.8,2.4,48.9"}})
+		require.Len(t, actual, 1)
+		require.Equal(t, "Jazz au café", actual[0].Name)
+	})
+}
+
+func TestSearchInvalidParams(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	for name, params := range map[string]url.Values{
+		"missing q":      {},
+		"q without word": {"q": {"\"*-"}},
+		"invalid bounds": {"q": {"jazz"}, "bounds": {"2.3,48.8,2.4"}},
+		"invalid to":     {"q": {"jazz"}, "to": {"tomorrow"}},
+		"to before from": {"q": {"jazz"}, "from": {"2025-11-24T00:00:00Z"}, "to": {"2025-11-23T00:00:00Z"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/search?%s", PORT, params.Encode()))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func search(t *testing.T, params url.Values) []searchResult {
+	t.Helper()
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/search?%s", PORT, params.Encode()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual []searchResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+	return actual
+}
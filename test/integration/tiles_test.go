@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/leorolland/sortir.in/pkg/application"
+	"github.com/leorolland/sortir.in/pkg/application/applicationtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTilesGetSuccess(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	now := time.Now()
+	paris := application.EventLocation{Lat: 48.8566, Lon: 2.3522}
+	events := applicationtest.MustValidateEvents(t, []application.Event{
+		{Name: "Concert 1", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: paris, Kind: application.KindConcert},
+		{Name: "Concert 2", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: paris, Kind: application.KindConcert},
+	})
+
+	resp, err := putEvents(t, events)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/api/tiles/%s.mvt", PORT, tilePath(paris, 14))
+
+	resp, body := getTile(t, url, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/vnd.mapbox-vector-tile", resp.Header.Get("Content-Type"))
+	require.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+	require.True(t, bytes.Contains(body, []byte("pins")))
+	require.True(t, bytes.Contains(body, []byte("concert")))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	t.Run("not modified", func(t *testing.T) {
+		resp, body := getTile(t, url, etag)
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+		require.Empty(t, body)
+		require.Equal(t, etag, resp.Header.Get("ETag"))
+	})
+
+	t.Run("empty tile elsewhere", func(t *testing.T) {
+		resp, body := getTile(t, fmt.Sprintf("http://127.0.0.1:%d/api/tiles/%s.mvt", PORT, tilePath(application.EventLocation{Lat: 45.764, Lon: 4.8357}, 14)), "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, bytes.Contains(body, []byte("concert")))
+		require.NotEqual(t, etag, resp.Header.Get("ETag"))
+	})
+
+	t.Run("filtered out", func(t *testing.T) {
+		resp, body := getTile(t, url+"?kinds=theater", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, bytes.Contains(body, []byte("concert")))
+	})
+
+	t.Run("modified when events change", func(t *testing.T) {
+		resp, err := putEvents(t, applicationtest.MustValidateEvents(t, []application.Event{
+			{Name: "Theater", Begin: now.Add(time.Hour), End: now.Add(2 * time.Hour), Loc: paris, Kind: application.KindTheater},
+		}))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body := getTile(t, url, etag)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, bytes.Contains(body, []byte("theater")))
+		require.NotEqual(t, etag, resp.Header.Get("ETag"))
+	})
+}
+
+func TestTilesGetInvalid(t *testing.T) {
+	_ = setupTestPocketBase(t)
+
+	testCases := map[string]struct {
+		path   string
+		status int
+	}{
+		"not mvt":        {path: "1/0/0.png", status: http.StatusNotFound},
+		"invalid x":      {path: "1/a/0.mvt", status: http.StatusNotFound},
+		"out of range":   {path: "1/2/0.mvt", status: http.StatusNotFound},
+		"invalid to":     {path: "1/0/0.mvt?to=tomorrow", status: http.StatusBadRequest},
+		"invalid filter": {path: "1/0/0.mvt?kinds=opera", status: http.StatusBadRequest},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp, _ := getTile(t, fmt.Sprintf("http://127.0.0.1:%d/api/tiles/%s", PORT, testCase.path), "")
+			require.Equal(t, testCase.status, resp.StatusCode)
+		})
+	}
+}
+
+// tilePath returns the z/x/y path of the tile containing loc at zoom z
+func tilePath(loc application.EventLocation, z int) string {
+	n := math.Exp2(float64(z))
+	lat := loc.Lat * math.Pi / 180
+	x := int((loc.Lon + 180) / 360 * n)
+	y := int((1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n)
+	return fmt.Sprintf("%d/%d/%d", z, x, y)
+}
+
+func getTile(t *testing.T, url string, ifNoneMatch string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, body
+}
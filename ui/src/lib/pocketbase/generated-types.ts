@@ -96,20 +96,23 @@ export type SuperusersRecord = {
 	verified?: boolean
 }
 
-export type EventsRecord<Tgenres = unknown> = {
+export type EventsRecord<Tgenres = unknown, Tsources = unknown> = {
 	address?: string
 	begin: IsoDateString
 	end: IsoDateString
+	fingerprint?: string
 	genres?: null | Tgenres
 	id: string
 	img?: string
 	kind: string
 	loc: GeoPoint
 	name: string
+	norm_name?: string
 	place?: string
 	price?: number
 	price_currency?: string
 	source?: string
+	sources?: null | Tsources
 }
 
 export type UsersRecord = {
@@ -131,7 +134,7 @@ export type ExternalauthsResponse<Texpand = unknown> = Required<ExternalauthsRec
 export type MfasResponse<Texpand = unknown> = Required<MfasRecord> & BaseSystemFields<Texpand>
 export type OtpsResponse<Texpand = unknown> = Required<OtpsRecord> & BaseSystemFields<Texpand>
 export type SuperusersResponse<Texpand = unknown> = Required<SuperusersRecord> & AuthSystemFields<Texpand>
-export type EventsResponse<Tgenres = unknown, Texpand = unknown> = Required<EventsRecord<Tgenres>> & BaseSystemFields<Texpand>
+export type EventsResponse<Tgenres = unknown, Tsources = unknown, Texpand = unknown> = Required<EventsRecord<Tgenres, Tsources>> & BaseSystemFields<Texpand>
 export type UsersResponse<Texpand = unknown> = Required<UsersRecord> & AuthSystemFields<Texpand>
 
 // Types containing all Records and Responses, useful for creating typing helper functions
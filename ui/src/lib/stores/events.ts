@@ -2,8 +2,30 @@ import { writable } from 'svelte/store';
 import { client } from '$lib/pocketbase';
 import type { EventsResponse, GeoPoint } from '$lib/pocketbase/generated-types';
 
+export type EventDetail = {
+  id: string;
+  name: string;
+  kind: string;
+  genres: string[];
+  begin: string;
+  end: string;
+  loc: GeoPoint;
+  place: string;
+  address: string;
+  price?: number;
+  price_currency?: string;
+  source: string;
+  sources: string[];
+  img: string;
+}
+
+export type SearchResult = EventDetail & {
+  // HTML escaped extract where matched words are surrounded by <mark> tags
+  snippet: string;
+}
+
 function createEventsStore() {
-  const { subscribe: subscribeEventsForLocation, set: setEventsForLocation } = writable<EventsResponse[]>([]);
+  const { subscribe: subscribeEventsForLocation, set: setEventsForLocation } = writable<EventDetail[]>([]);
   const { subscribe: subscribeEventsForBounds, set: setEventsForBounds } = writable<EventsResponse[]>([]);
 
   return {
@@ -12,16 +34,20 @@ function createEventsStore() {
 
     loadEventsForLocation: async (location: GeoPoint, maxDate: Date) => {
       try {
-        let filter = `(begin<='${maxDate.toISOString()}'&&loc.lat=${location.lat}&&loc.lon=${location.lon})`;
+        const url = new URL('/api/events', window.location.origin);
+        url.searchParams.append('lat', location.lat.toString());
+        url.searchParams.append('lon', location.lon.toString());
+        url.searchParams.append('to', maxDate.toISOString());
 
-        const eventsResult = await client.collection('events').getList<EventsResponse>(
-          1,
-          100,
-          { filter, skipTotal: true }
-        );
+        const response = await fetch(url.toString());
+        if (!response.ok) {
+          throw new Error(`Failed to fetch events: ${response.statusText}`);
+        }
 
-        setEventsForLocation(eventsResult.items);
-        return eventsResult.items;
+        const events: EventDetail[] = await response.json();
+
+        setEventsForLocation(events);
+        return events;
       } catch (error) {
         console.error('Error loading events for location:', error);
         setEventsForLocation([]);
@@ -29,6 +55,46 @@ function createEventsStore() {
       }
     },
 
+    getEvent: async (id: string): Promise<EventDetail | null> => {
+      try {
+        const response = await fetch(new URL(`/api/events/${encodeURIComponent(id)}`, window.location.origin).toString());
+        if (!response.ok) {
+          throw new Error(`Failed to fetch event: ${response.statusText}`);
+        }
+        return await response.json();
+      } catch (error) {
+        console.error('Error loading event:', error);
+        return null;
+      }
+    },
+
+    searchEvents: async (q: string, bounds?: {
+      getNorth: () => number;
+      getSouth: () => number;
+      getEast: () => number;
+      getWest: () => number;
+    }, to?: Date): Promise<SearchResult[]> => {
+      try {
+        const url = new URL('/api/search', window.location.origin);
+        url.searchParams.append('q', q);
+        if (bounds) {
+          url.searchParams.append('bounds', [bounds.getWest(), bounds.getSouth(), bounds.getEast(), bounds.getNorth()].join(','));
+        }
+        if (to) {
+          url.searchParams.append('to', to.toISOString());
+        }
+
+        const response = await fetch(url.toString());
+        if (!response.ok) {
+          throw new Error(`Failed to search events: ${response.statusText}`);
+        }
+        return await response.json();
+      } catch (error) {
+        console.error('Error searching events:', error);
+        return [];
+      }
+    },
+
     getEventsInBounds: async (bounds: {
       getNorth: () => number;
       getSouth: () => number;
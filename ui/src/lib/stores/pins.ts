@@ -7,6 +7,25 @@ export type Pin = {
   };
   kind: string;
   amount: number;
+  kinds?: Record<string, number>;
+  // earliest begin of the events which have not begun yet, ISO 8601
+  next_begin?: string;
+}
+
+// CLUSTER_MAX_ZOOM mirrors the highest zoom level at which the server clusters pins
+export const CLUSTER_MAX_ZOOM = 11;
+
+// isCluster tells if the pin gathers the events of a cell, located at their average location rather than at a place
+export function isCluster(pin: Pin): boolean {
+  return pin.kinds !== undefined && pin.kinds !== null;
+}
+
+export type PinsFilter = {
+  kinds?: string[];
+  excludeKinds?: string[];
+  genres?: string[];
+  freeOnly?: boolean;
+  maxPrice?: number;
 }
 
 export interface MapBounds {
@@ -23,7 +42,7 @@ function createPinsStore() {
 
   return {
     subscribe,
-    loadPins: async (bounds: MapBounds, maxBeginDate: Date) => {
+    loadPins: async (bounds: MapBounds, to: Date, zoom?: number, filter: PinsFilter = {}, from?: Date) => {
       currentBounds = bounds;
       try {
         const url = new URL('/api/pins', window.location.origin);
@@ -31,7 +50,28 @@ function createPinsStore() {
         url.searchParams.append('south', bounds.getSouth().toString());
         url.searchParams.append('east', bounds.getEast().toString());
         url.searchParams.append('west', bounds.getWest().toString());
-        url.searchParams.append('max_time', maxBeginDate.toISOString());
+        url.searchParams.append('to', to.toISOString());
+        if (from) {
+          url.searchParams.append('from', from.toISOString());
+        }
+        if (zoom !== undefined) {
+          url.searchParams.append('zoom', Math.floor(zoom).toString());
+        }
+        if (filter.kinds?.length) {
+          url.searchParams.append('kinds', filter.kinds.join(','));
+        }
+        if (filter.excludeKinds?.length) {
+          url.searchParams.append('exclude_kinds', filter.excludeKinds.join(','));
+        }
+        if (filter.genres?.length) {
+          url.searchParams.append('genres', filter.genres.join(','));
+        }
+        if (filter.freeOnly) {
+          url.searchParams.append('free', 'true');
+        }
+        if (filter.maxPrice !== undefined) {
+          url.searchParams.append('max_price', filter.maxPrice.toString());
+        }
 
         const response = await fetch(url.toString());
         if (!response.ok) {